@@ -7,15 +7,21 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/maruqu/alien-invasion/internal/render"
+	"github.com/maruqu/alien-invasion/internal/simulation"
 	"github.com/maruqu/alien-invasion/internal/util"
 	"github.com/maruqu/alien-invasion/internal/world"
 )
 
 var (
 	analyzeCmd = &cobra.Command{
-		Use:   "analyze [initial map file] [result map file] [initial dot file] [output dot file]",
+		Use:   "analyze [initial map file] [result map file] [[initial dot file]] [output dot file]",
 		Short: "Generate a graph in dot format from the simulation result with destroyed cities marked red.",
-		Args:  cobra.ExactArgs(4),
+		Long: "Generate a graph in dot format from the simulation result with destroyed cities marked red.\n\n" +
+			"With four arguments, the cities destroyed in the result map are marked in the initial dot file.\n" +
+			"With three arguments, the third one is the output dot file and the graph is generated from city coordinates\n" +
+			"stored in the initial map (or a layout inferred from the roads if coordinates are missing).",
+		Args: cobra.RangeArgs(3, 4),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			initialWorldMap, err := world.Load(args[0])
 			if err != nil {
@@ -27,27 +33,20 @@ var (
 				return fmt.Errorf("error loading result map: %w", err)
 			}
 
-			var destroyedCities []string
-			for city, _ := range initialWorldMap {
-				if _, ok := resultWorldMap[city]; !ok {
-					destroyedCities = append(destroyedCities, string(city))
+			var graph string
+			if len(args) == 3 {
+				graph, err = render.DotGraph(initialWorldMap, resultWorldMap)
+				if err != nil {
+					return fmt.Errorf("error generating dot format graph: %w", err)
+				}
+			} else {
+				graph, err = markDestroyedCities(args[2], initialWorldMap, resultWorldMap)
+				if err != nil {
+					return err
 				}
 			}
 
-			b, err := ioutil.ReadFile(args[2])
-			if err != nil {
-				return fmt.Errorf("error reading dot graph: %w", err)
-			}
-			graph := string(b)
-
-			// add background color property to destroyed nodes
-			for _, city := range destroyedCities {
-				oldAttrs := fmt.Sprintf("[label=\"%s\"]", city)
-				newAttrs := fmt.Sprintf("[label=\"%s\", fillcolor=\"red\"]", city)
-				graph = strings.Replace(graph, oldAttrs, newAttrs, 1)
-			}
-
-			err = util.Write(args[3], graph)
+			err = util.Write(args[len(args)-1], graph)
 			if err != nil {
 				return fmt.Errorf("error writing generated dot graph to file: %w", err)
 			}
@@ -56,3 +55,28 @@ var (
 		},
 	}
 )
+
+// markDestroyedCities reads an existing dot graph of the initial map and marks cities missing from the result red.
+func markDestroyedCities(filepath string, initialWorldMap, resultWorldMap simulation.WorldMap) (string, error) {
+	var destroyedCities []string
+	for city := range initialWorldMap {
+		if _, ok := resultWorldMap[city]; !ok {
+			destroyedCities = append(destroyedCities, string(city))
+		}
+	}
+
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return "", fmt.Errorf("error reading dot graph: %w", err)
+	}
+	graph := string(b)
+
+	// add background color property to destroyed nodes
+	for _, city := range destroyedCities {
+		oldAttrs := fmt.Sprintf("[label=\"%s\"]", city)
+		newAttrs := fmt.Sprintf("[label=\"%s\", fillcolor=\"red\"]", city)
+		graph = strings.Replace(graph, oldAttrs, newAttrs, 1)
+	}
+
+	return graph, nil
+}
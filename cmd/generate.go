@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -13,6 +14,8 @@ const (
 	defaultGridHeight  = 5
 	defaultGridWidth   = 5
 	defaultCitiesCount = 20
+	defaultSparsity    = 0.3
+	defaultIslands     = 4
 )
 
 var (
@@ -20,13 +23,51 @@ var (
 	gridWidth        int
 	citiesCount      int
 	dotGraphFilepath string
+	asciiMap         bool
+	topology         string
+	sparsity         float64
+	islands          int
+	constraints      mapgen.Constraints
+	namesFilepath    string
+	naming           string
+	maskFilepath     string
+	diagonals        bool
 
 	generateCmd = &cobra.Command{
 		Use:   "generate [output map file]",
 		Short: "Generate a world map",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			gridMap, err := mapgen.NewGridMap(gridHeight, gridWidth, citiesCount)
+			var names []string
+			if namesFilepath != "" {
+				var err error
+				names, err = util.ReadLines(namesFilepath)
+				if err != nil {
+					return fmt.Errorf("error reading city names: %w", err)
+				}
+			}
+
+			var mask *mapgen.Mask
+			if maskFilepath != "" {
+				var err error
+				mask, err = mapgen.LoadMask(maskFilepath)
+				if err != nil {
+					return fmt.Errorf("error reading mask: %w", err)
+				}
+
+				gridHeight, gridWidth = mask.Height(), mask.Width()
+			}
+
+			gridMap, err := mapgen.NewGridMap(gridHeight, gridWidth, citiesCount, mapgen.Options{
+				Topology:    mapgen.Topology(topology),
+				Sparsity:    sparsity,
+				Islands:     islands,
+				Constraints: constraints,
+				Names:       names,
+				Naming:      mapgen.Naming(naming),
+				Mask:        mask,
+				Diagonals:   diagonals,
+			})
 			if err != nil {
 				return fmt.Errorf("error generating map: %w", err)
 			}
@@ -48,6 +89,10 @@ var (
 				}
 			}
 
+			if asciiMap {
+				fmt.Print(gridMap.ASCII())
+			}
+
 			return nil
 		},
 	}
@@ -58,4 +103,32 @@ func init() {
 	generateCmd.Flags().IntVarP(&gridWidth, "width", "", defaultGridWidth, "grid width")
 	generateCmd.Flags().IntVarP(&citiesCount, "cities", "c", defaultCitiesCount, "cities count")
 	generateCmd.Flags().StringVarP(&dotGraphFilepath, "dot", "d", "", "output dot file (graphviz format)")
+	generateCmd.Flags().StringVarP(&topology, "topology", "t", string(mapgen.TopologyGrid), fmt.Sprintf("roads topology (%s)", topologyNames()))
+	generateCmd.Flags().Float64VarP(&sparsity, "sparsity", "", defaultSparsity, "fraction of roads removed by the sparse topology")
+	generateCmd.Flags().IntVarP(&islands, "islands", "", defaultIslands, "islands count of the islands topology")
+	generateCmd.Flags().BoolVarP(&constraints.Connected, "connected", "", false, "require all cities to be connected")
+	generateCmd.Flags().IntVarP(&constraints.MinDegree, "min-degree", "", 0, "minimal number of roads leading out of a city")
+	generateCmd.Flags().IntVarP(&constraints.MaxDegree, "max-degree", "", 0, "maximal number of roads leading out of a city (no limit by default)")
+	generateCmd.Flags().Float64VarP(&constraints.AverageDegree, "avg-degree", "", 0, "target average number of roads leading out of a city")
+	generateCmd.Flags().StringVarP(&namesFilepath, "names-file", "", "", "file with city names, one per line (embedded list by default)")
+	generateCmd.Flags().StringVarP(&naming, "naming", "", string(mapgen.NamingFirst), fmt.Sprintf("city naming (%s)", namingNames()))
+	generateCmd.Flags().StringVarP(&maskFilepath, "mask", "", "", "mask file (ASCII text or PNG image) with land and impassable cells, overrides grid size")
+	generateCmd.Flags().BoolVarP(&diagonals, "diagonals", "", false, "connect cities on the same diagonal (8-connected grid)")
+	generateCmd.Flags().BoolVarP(&asciiMap, "ascii", "", false, "print the map as ASCII art")
+}
+
+func topologyNames() string {
+	names := make([]string, len(mapgen.Topologies))
+	for i, topology := range mapgen.Topologies {
+		names[i] = string(topology)
+	}
+	return strings.Join(names, ", ")
+}
+
+func namingNames() string {
+	names := make([]string, len(mapgen.Namings))
+	for i, naming := range mapgen.Namings {
+		names[i] = string(naming)
+	}
+	return strings.Join(names, ", ")
 }
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+)
+
+const (
+	progressBarWidth    = 30
+	progressBarInterval = 100 * time.Millisecond
+)
+
+// progressBar draws the progress of a simulation on the last line of a terminal.
+// It is also a writer for the log, so that log lines are printed above the bar.
+type progressBar struct {
+	w      io.Writer
+	last   time.Time
+	latest simulation.Progress
+	drawn  bool
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+// Report updates the progress, the bar is redrawn at most every progressBarInterval.
+func (b *progressBar) Report(progress simulation.Progress) {
+	b.latest = progress
+	if now := time.Now(); now.Sub(b.last) >= progressBarInterval {
+		b.last = now
+		b.draw()
+	}
+}
+
+// Write clears the bar, writes a log line and draws the bar again below it.
+func (b *progressBar) Write(p []byte) (int, error) {
+	if b.drawn {
+		fmt.Fprint(b.w, "\r\033[K")
+	}
+
+	n, err := b.w.Write(p)
+	if b.drawn {
+		b.draw()
+	}
+	return n, err
+}
+
+// Finish draws the final progress and ends the line of the bar.
+func (b *progressBar) Finish() {
+	if b.drawn {
+		b.draw()
+		fmt.Fprintln(b.w)
+		b.drawn = false
+	}
+}
+
+func (b *progressBar) draw() {
+	filled := progressBarWidth
+	if b.latest.IterationLimit > 0 && b.latest.Iteration < b.latest.IterationLimit {
+		filled = progressBarWidth * b.latest.Iteration / b.latest.IterationLimit
+	}
+
+	fmt.Fprintf(
+		b.w, "\r\033[K[%s%s] iteration %d/%d, aliens: %d, cities: %d",
+		strings.Repeat("#", filled), strings.Repeat(" ", progressBarWidth-filled),
+		b.latest.Iteration, b.latest.IterationLimit, b.latest.Aliens, b.latest.Cities,
+	)
+	b.drawn = true
+}
@@ -17,4 +17,5 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(shellCmd)
 }
@@ -1,49 +1,125 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/maruqu/alien-invasion/internal/render"
+	"github.com/maruqu/alien-invasion/internal/scenario"
 	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/util"
 	"github.com/maruqu/alien-invasion/internal/world"
 )
 
 const (
-	defaultIterationsLimit = 10000
-	defaultAliensCount     = 50
+	defaultIterationsLimit  = 10000
+	defaultAliensCount      = 50
+	defaultSquadDeathChance = 0.5
+	defaultCheckpointEvery  = 100
 )
 
 var (
-	iterationsLimit   int
-	aliensCount       int
-	outputMapFilepath string
+	iterationsLimit      int
+	aliensCount          int
+	outputMapFilepath    string
+	asciiResult          bool
+	alienNamesFilepath   string
+	alienNaming          string
+	safeAlienNames       bool
+	factions             string
+	collisionRule        = simulation.DefaultCollisionRule
+	squadsCount          int
+	squadStrategy        string
+	squadDeathChance     float64
+	waves                []string
+	reproductionTurns    int
+	fuel                 int
+	reroute              bool
+	placement            string
+	landingZones         int
+	placementFilepath    string
+	seed                 int64
+	stopAliens           int
+	stopCities           int
+	scenarioFilepath     string
+	saveScenarioFilepath string
+	checkpointFilepath   string
+	checkpointEvery      int
+	resumeFilepath       string
+	timeout              time.Duration
+	showProgress         bool
 
 	runCmd = &cobra.Command{
 		Use:   "run [input map file]",
 		Short: "Run simulation",
-		Args:  cobra.ExactArgs(1),
+		Long: "Run simulation on a provided map.\n\n" +
+			"The simulation can be described by a JSON scenario file (--scenario) in place of the flags.\n" +
+			"The input map file overrides the map of the scenario.\n\n" +
+			"The state of the simulation can be saved periodically (--checkpoint) and the simulation\n" +
+			"can be continued from a saved checkpoint (--resume).\n\n" +
+			"The simulation can be interrupted with Ctrl+C or a timeout (--timeout), the partial result is reported\n" +
+			"and a checkpoint is saved if configured.",
+		Args: cobra.RangeArgs(0, 1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			log.SetFlags(0)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			worldMap, err := world.Load(args[0])
+			var sim *simulation.Simulation
+			var err error
+
+			if resumeFilepath != "" {
+				sim, err = resumeSimulation(cmd, args)
+			} else {
+				sim, err = newSimulation(cmd, args)
+			}
 			if err != nil {
-				return fmt.Errorf("error loading world map: %w", err)
+				return err
 			}
 
-			simulation, err := simulation.NewSimulation(
-				iterationsLimit,
-				aliensCount,
-				worldMap,
-			)
-			if err != nil {
-				return fmt.Errorf("error initializing simulation: %w", err)
+			if checkpointFilepath == "" {
+				checkpointFilepath = resumeFilepath
+			}
+			if checkpointFilepath != "" {
+				sim.SetCheckpoint(checkpointEvery, func(data []byte) error {
+					return util.WriteAtomic(checkpointFilepath, data)
+				})
 			}
 
-			result, err := simulation.Run()
-			if err != nil {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			var bar *progressBar
+			if showProgress {
+				bar = newProgressBar(os.Stderr)
+				sim.SetProgress(bar.Report)
+				log.SetOutput(bar)
+			}
+
+			result, err := sim.Run(ctx)
+
+			if bar != nil {
+				bar.Finish()
+				log.SetOutput(os.Stderr)
+			}
+
+			// an interrupted simulation reports its partial result
+			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
 				return fmt.Errorf("error running simulation: %w", err)
 			}
 
@@ -52,7 +128,11 @@ var (
 				if err != nil {
 					return fmt.Errorf("error saving result world map: %w", err)
 				}
-			} else {
+			}
+
+			if asciiResult {
+				log.Printf("\nWorld map after invasion:\n\n%s", render.ASCII(sim.InitialMap(), result))
+			} else if outputMapFilepath == "" {
 				if len(result) == 0 {
 					log.Println("Whole world destroyed!")
 				} else {
@@ -60,6 +140,14 @@ var (
 				}
 			}
 
+			for _, faction := range sim.FactionSurvivors() {
+				log.Printf("Faction %s: %d of %d aliens survived", faction.Name, faction.Survivors, faction.Size)
+			}
+
+			if survivors, total := sim.SquadSurvivors(); total > 0 {
+				log.Printf("Human squads: %d of %d survived", survivors, total)
+			}
+
 			return nil
 		},
 	}
@@ -69,4 +157,275 @@ func init() {
 	runCmd.Flags().IntVarP(&iterationsLimit, "iterations", "i", defaultIterationsLimit, "iterations limit")
 	runCmd.Flags().IntVarP(&aliensCount, "aliens", "a", defaultAliensCount, "aliens count")
 	runCmd.Flags().StringVarP(&outputMapFilepath, "output", "o", "", "output world map file (printed to STDOUT by default)")
+	runCmd.Flags().BoolVarP(&asciiResult, "ascii", "", false, "print the result as ASCII art with destroyed cities marked")
+	runCmd.Flags().StringVarP(&alienNamesFilepath, "alien-names", "", "", "file with alien names, one per line (embedded list by default)")
+	runCmd.Flags().StringVarP(&alienNaming, "alien-naming", "", string(simulation.AlienNamingList), fmt.Sprintf("alien naming (%s)", alienNamingNames()))
+	runCmd.Flags().StringVarP(&factions, "factions", "", "", "alien factions with their sizes, e.g. red=10,blue=20 (overrides aliens count)")
+	runCmd.Flags().IntVarP(&collisionRule.MinFighters, "min-fighters", "", simulation.DefaultCollisionRule.MinFighters, "minimal number of aliens in a city starting a fight")
+	runCmd.Flags().Float64VarP(&collisionRule.DestructionChance, "destruction-chance", "", simulation.DefaultCollisionRule.DestructionChance, "probability that a fight destroys the city")
+	runCmd.Flags().BoolVarP(&collisionRule.RandomSurvivor, "random-survivor", "", false, "keep one random alien alive after a fight which does not destroy the city")
+	runCmd.Flags().IntVarP(&collisionRule.Capacity, "capacity", "", 0, "maximal number of aliens in a city before it collapses (no limit by default)")
+	runCmd.Flags().BoolVarP(&collisionRule.RoadFights, "road-fights", "", false, "enemy aliens travelling along the same road in opposite directions fight and destroy the road")
+	runCmd.Flags().BoolVarP(&collisionRule.RoadFightsDestroyCities, "road-fights-destroy-cities", "", false, "road fights destroy the cities at both ends of the road")
+	runCmd.Flags().IntVarP(&squadsCount, "squads", "", 0, "human resistance squads count")
+	runCmd.Flags().StringVarP(&squadStrategy, "squad-strategy", "", string(simulation.SquadStrategyRandom), fmt.Sprintf("movement strategy of human squads (%s)", squadStrategyNames()))
+	runCmd.Flags().Float64VarP(&squadDeathChance, "squad-death-chance", "", defaultSquadDeathChance, "probability that a squad dies in a fight with an alien")
+	runCmd.Flags().StringArrayVarP(&waves, "wave", "", nil, "reinforcement wave landing at an iteration, iteration:aliens[:city,...[:faction]] (repeatable)")
+	runCmd.Flags().IntVarP(&reproductionTurns, "reproduction", "", 0, "turns an alien has to spend alone in a city to spawn another alien (disabled by default)")
+	runCmd.Flags().IntVarP(&fuel, "fuel", "", 0, "fuel of every alien spent on travelling along the roads (unlimited by default)")
+	runCmd.Flags().BoolVarP(&reroute, "reroute", "", false, "aliens travelling to a destroyed city turn back instead of being stranded")
+	runCmd.Flags().StringVarP(&placement, "placement", "", string(simulation.PlacementRandom), fmt.Sprintf("initial alien placement (%s)", placementNames()))
+	runCmd.Flags().IntVarP(&landingZones, "landing-zones", "", 1, "landing zones count of the clustered placement")
+	runCmd.Flags().StringVarP(&placementFilepath, "placement-file", "", "", "file with a fixed alien placement, one \"alien city\" per line (overrides --placement)")
+	runCmd.Flags().IntVarP(&stopAliens, "stop-aliens", "", 0, "stop when at most this number of aliens is left")
+	runCmd.Flags().IntVarP(&stopCities, "stop-cities", "", 0, "stop when at most this number of cities is left")
+	runCmd.Flags().StringVarP(&scenarioFilepath, "scenario", "", "", "JSON scenario file describing the simulation in place of the flags")
+	runCmd.Flags().StringVarP(&saveScenarioFilepath, "save-scenario", "", "", "save the simulation as a JSON scenario file (including the seed) to repeat it later")
+	runCmd.Flags().StringVarP(&checkpointFilepath, "checkpoint", "", "", "save the state of the simulation to a checkpoint file periodically (the resumed file by default)")
+	runCmd.Flags().IntVarP(&checkpointEvery, "checkpoint-every", "", defaultCheckpointEvery, "iterations between checkpoints")
+	runCmd.Flags().StringVarP(&resumeFilepath, "resume", "", "", "resume the simulation from a checkpoint file")
+	runCmd.Flags().DurationVarP(&timeout, "timeout", "", 0, "interrupt the simulation after a provided duration, e.g. 30s (no timeout by default)")
+	runCmd.Flags().BoolVarP(&showProgress, "progress", "", false, "show a progress bar")
+	runCmd.Flags().Int64VarP(&seed, "seed", "", 0, "random seed (time based by default)")
+	runCmd.Flags().BoolVarP(&safeAlienNames, "safe-names", "", false, "replace whitespaces and special characters in alien names")
+}
+
+// newSimulation returns a simulation described by the scenario file or the flags.
+func newSimulation(cmd *cobra.Command, args []string) (*simulation.Simulation, error) {
+	sc, err := loadScenario(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Flags().Changed("seed") {
+		sc.Seed = &seed
+	}
+	if sc.Seed == nil {
+		seed := time.Now().UTC().UnixNano()
+		sc.Seed = &seed
+	}
+
+	if saveScenarioFilepath != "" {
+		err = scenario.Save(saveScenarioFilepath, sc)
+		if err != nil {
+			return nil, fmt.Errorf("error saving scenario: %w", err)
+		}
+	}
+
+	sim, _, err := sc.NewSimulation()
+	return sim, err
+}
+
+// resumeFlags can be combined with resuming a simulation, the other flags are stored in the checkpoint.
+var resumeFlags = map[string]struct{}{
+	"resume":           {},
+	"checkpoint":       {},
+	"checkpoint-every": {},
+	"output":           {},
+	"ascii":            {},
+	"timeout":          {},
+	"progress":         {},
+}
+
+// resumeSimulation returns a simulation restored from the checkpoint file.
+func resumeSimulation(cmd *cobra.Command, args []string) (*simulation.Simulation, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("input map file cannot be combined with resuming a simulation")
+	}
+	if err := onlyFlags(cmd, resumeFlags, "resuming a simulation"); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(resumeFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	sim, err := simulation.Resume(data)
+	if err != nil {
+		return nil, fmt.Errorf("error resuming simulation: %w", err)
+	}
+
+	return sim, nil
+}
+
+// onlyFlags returns an error if a flag other than the allowed flags was set.
+func onlyFlags(cmd *cobra.Command, allowed map[string]struct{}, context string) error {
+	var err error
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if _, ok := allowed[flag.Name]; !ok && err == nil {
+			err = fmt.Errorf("flag --%s cannot be combined with %s", flag.Name, context)
+		}
+	})
+	return err
+}
+
+// scenarioFlags can be combined with a scenario file, the other flags are described by the scenario.
+var scenarioFlags = map[string]struct{}{
+	"scenario":      {},
+	"save-scenario": {},
+	"seed":          {},
+	"output":        {},
+	"ascii":         {},
+	"timeout":       {},
+	"progress":      {},
+}
+
+// loadScenario returns a scenario loaded from the scenario file or described by the flags.
+func loadScenario(cmd *cobra.Command, args []string) (*scenario.Scenario, error) {
+	if scenarioFilepath != "" {
+		if err := onlyFlags(cmd, scenarioFlags, "a scenario file"); err != nil {
+			return nil, err
+		}
+
+		sc, err := scenario.Load(scenarioFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading scenario: %w", err)
+		}
+
+		if len(args) == 1 {
+			sc.Map, sc.InlineMap = args[0], nil
+		}
+
+		return sc, nil
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("input map file or scenario file is required")
+	}
+
+	sc := &scenario.Scenario{
+		Map:           args[0],
+		Aliens:        aliensCount,
+		AlienNaming:   simulation.AlienNaming(alienNaming),
+		SafeNames:     safeAlienNames,
+		Placement:     simulation.Placement(placement),
+		LandingZones:  landingZones,
+		CollisionRule: collisionRule,
+		Squads: scenario.Squads{
+			Count:       squadsCount,
+			Strategy:    simulation.SquadStrategy(squadStrategy),
+			DeathChance: squadDeathChance,
+		},
+		Reproduction: reproductionTurns,
+		Fuel:         fuel,
+		Reroute:      reroute,
+		Stop:         scenario.Stop{Iterations: iterationsLimit, Aliens: stopAliens, Cities: stopCities},
+	}
+
+	var err error
+	if alienNamesFilepath != "" {
+		sc.AlienNames, err = util.ReadLines(alienNamesFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading alien names: %w", err)
+		}
+	}
+
+	sc.Factions, err = parseFactions(factions)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing factions: %w", err)
+	}
+
+	if placementFilepath != "" {
+		sc.FixedPlacement, err = world.LoadPlacement(placementFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading placement: %w", err)
+		}
+		if sc.Factions == nil && !cmd.Flags().Changed("aliens") {
+			sc.Aliens = len(sc.FixedPlacement)
+		}
+	}
+
+	sc.Waves, err = parseWaves(waves)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing waves: %w", err)
+	}
+
+	return sc, nil
+}
+
+func alienNamingNames() string {
+	names := make([]string, len(simulation.AlienNamings))
+	for i, naming := range simulation.AlienNamings {
+		names[i] = string(naming)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseFactions parses a comma separated list of factions with their sizes (name=size).
+func parseFactions(text string) ([]simulation.Faction, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	var result []simulation.Faction
+	for _, part := range strings.Split(text, ",") {
+		fields := strings.SplitN(part, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("missing size of faction: %s", part)
+		}
+		name, size := fields[0], fields[1]
+
+		count, err := strconv.Atoi(size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size of faction %s: %w", name, err)
+		}
+
+		result = append(result, simulation.Faction{Name: strings.TrimSpace(name), Size: count})
+	}
+
+	return result, nil
+}
+
+func placementNames() string {
+	names := make([]string, len(simulation.Placements))
+	for i, placement := range simulation.Placements {
+		names[i] = string(placement)
+	}
+	return strings.Join(names, ", ")
+}
+
+func squadStrategyNames() string {
+	names := make([]string, len(simulation.SquadStrategies))
+	for i, strategy := range simulation.SquadStrategies {
+		names[i] = string(strategy)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseWaves parses reinforcement waves in format iteration:aliens[:city,...[:faction]].
+func parseWaves(texts []string) ([]simulation.Wave, error) {
+	result := make([]simulation.Wave, 0, len(texts))
+
+	for _, text := range texts {
+		fields := strings.Split(text, ":")
+		if len(fields) < 2 || len(fields) > 4 {
+			return nil, fmt.Errorf("invalid wave: %s", text)
+		}
+
+		iteration, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid iteration of wave %s: %w", text, err)
+		}
+
+		aliens, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid aliens count of wave %s: %w", text, err)
+		}
+
+		wave := simulation.Wave{Iteration: iteration, Aliens: aliens}
+
+		if len(fields) > 2 && fields[2] != "" {
+			for _, city := range strings.Split(fields[2], ",") {
+				wave.Cities = append(wave.Cities, simulation.City(city))
+			}
+		}
+		if len(fields) > 3 {
+			wave.Faction = fields[3]
+		}
+
+		result = append(result, wave)
+	}
+
+	return result, nil
 }
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/maruqu/alien-invasion/internal/scenario"
+	"github.com/maruqu/alien-invasion/internal/shell"
+)
+
+var (
+	shellAliensCount      int
+	shellIterationsLimit  int
+	shellSeed             int64
+	shellScenarioFilepath string
+
+	shellCmd = &cobra.Command{
+		Use:   "shell [input map file]",
+		Short: "Drive a simulation interactively",
+		Long: "Drive a simulation interactively with commands read from STDIN (type help to list them).\n\n" +
+			"The simulation starts without aliens unless --aliens or a scenario file (--scenario) is provided,\n" +
+			"so that test situations can be crafted by placing aliens and destroying cities by hand.",
+		Args: cobra.RangeArgs(0, 1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			log.SetFlags(0)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sc := scenario.Default()
+			sc.Aliens = shellAliensCount
+			sc.Stop.Iterations = shellIterationsLimit
+
+			if shellScenarioFilepath != "" {
+				var err error
+				sc, err = scenario.Load(shellScenarioFilepath)
+				if err != nil {
+					return fmt.Errorf("error loading scenario: %w", err)
+				}
+
+				// flags set explicitly override the scenario
+				if cmd.Flags().Changed("aliens") {
+					sc.Aliens = shellAliensCount
+				}
+				if cmd.Flags().Changed("iterations") {
+					sc.Stop.Iterations = shellIterationsLimit
+				}
+			}
+
+			if len(args) == 1 {
+				sc.Map, sc.InlineMap = args[0], nil
+			}
+			if cmd.Flags().Changed("seed") {
+				sc.Seed = &shellSeed
+			}
+			if sc.Seed == nil {
+				seed := time.Now().UTC().UnixNano()
+				sc.Seed = &seed
+			}
+
+			sim, _, err := sc.NewSimulation()
+			if err != nil {
+				return err
+			}
+
+			return shell.New(sim, os.Stdout).Run(os.Stdin)
+		},
+	}
+)
+
+func init() {
+	shellCmd.Flags().IntVarP(&shellAliensCount, "aliens", "a", 0, "initial aliens count")
+	shellCmd.Flags().IntVarP(&shellIterationsLimit, "iterations", "i", defaultIterationsLimit, "iterations limit")
+	shellCmd.Flags().Int64VarP(&shellSeed, "seed", "", 0, "random seed (time based by default)")
+	shellCmd.Flags().StringVarP(&shellScenarioFilepath, "scenario", "", "", "JSON scenario file describing the simulation (the input map file and the --aliens, --iterations and --seed flags override it)")
+}
@@ -0,0 +1,245 @@
+package mapgen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/maruqu/alien-invasion/internal/util"
+)
+
+// maxAttempts is a number of times cities are placed on the grid before giving up on the constraints.
+const maxAttempts = 20
+
+// Constraints describe required properties of the generated roads.
+// Zero values mean no constraint.
+type Constraints struct {
+	// Connected requires every city to be reachable from any other city.
+	Connected bool
+
+	// MinDegree is a minimal number of roads leading out of every city.
+	MinDegree int
+
+	// MaxDegree is a maximal number of roads leading out of every city.
+	MaxDegree int
+
+	// AverageDegree is a target average number of roads leading out of a city.
+	AverageDegree float64
+}
+
+// validate rejects constraints which can never be satisfied
+// with roads leading in a provided number of directions.
+func (c Constraints) validate(citiesCount, directions int) error {
+	maxDegree := c.maxDegree()
+	if c.MaxDegree == 0 {
+		maxDegree = directions
+	}
+
+	switch {
+	case c.MinDegree < 0 || c.MaxDegree < 0 || c.AverageDegree < 0:
+		return fmt.Errorf("degree constraints cannot be negative")
+	case maxDegree > directions:
+		return fmt.Errorf("maximal degree cannot exceed %d", directions)
+	case c.MinDegree > maxDegree:
+		return fmt.Errorf("minimal degree (%d) exceeds maximal degree (%d)", c.MinDegree, maxDegree)
+	case c.MinDegree >= citiesCount && c.MinDegree > 0:
+		return fmt.Errorf("minimal degree (%d) requires more than %d cities", c.MinDegree, citiesCount)
+	case c.AverageDegree > 0 && (c.AverageDegree < float64(c.MinDegree) || c.AverageDegree > float64(maxDegree)):
+		return fmt.Errorf("average degree (%.2f) outside of degree limits (%d-%d)", c.AverageDegree, c.MinDegree, maxDegree)
+	case c.Connected && citiesCount > 2 && maxDegree < 2:
+		return fmt.Errorf("%d cities cannot be connected with maximal degree %d", citiesCount, maxDegree)
+	case c.Connected && c.AverageDegree > 0 && c.AverageDegree < 2*float64(citiesCount-1)/float64(citiesCount):
+		return fmt.Errorf("%d cities cannot be connected with average degree %.2f", citiesCount, c.AverageDegree)
+	}
+
+	return nil
+}
+
+func (c Constraints) maxDegree() int {
+	if c.MaxDegree == 0 {
+		return int(directionsCount)
+	}
+	return c.MaxDegree
+}
+
+// applyConstraints removes roads or restores possible roads to satisfy the constraints.
+// An error is returned if the constraints cannot be satisfied.
+func (gm *GridMap) applyConstraints(c Constraints, possibleRoads []neighbors) error {
+	maxDegree := c.maxDegree()
+
+	// remove roads exceeding the maximal degree
+	for i := range gm.roads {
+		for _, r := range gm.roadsOf(i) {
+			if gm.degree(i) <= maxDegree {
+				break
+			}
+			gm.removeRoad(r)
+		}
+	}
+
+	missing := gm.missingRoads(possibleRoads)
+	canAdd := func(r road) bool {
+		return gm.roads[r.city][r.direction] == noCity &&
+			gm.degree(r.city) < maxDegree &&
+			gm.degree(possibleRoads[r.city][r.direction]) < maxDegree
+	}
+
+	// join separate parts of the map
+	if c.Connected {
+		components := gm.components()
+		for _, r := range missing {
+			if canAdd(r) && components.Union(r.city, possibleRoads[r.city][r.direction]) {
+				gm.addRoad(r, possibleRoads)
+			}
+		}
+	}
+
+	// add roads to cities with too few roads
+	for _, r := range missing {
+		target := possibleRoads[r.city][r.direction]
+		if (gm.degree(r.city) < c.MinDegree || gm.degree(target) < c.MinDegree) && canAdd(r) {
+			gm.addRoad(r, possibleRoads)
+		}
+	}
+
+	// add or remove roads to get close to the average degree
+	if c.AverageDegree > 0 {
+		target := int(math.Round(c.AverageDegree * float64(len(gm.cities)) / 2))
+
+		for _, r := range missing {
+			if gm.roadsCount() >= target {
+				break
+			}
+			if canAdd(r) {
+				gm.addRoad(r, possibleRoads)
+			}
+		}
+
+		// roads of a spanning tree are never removed to keep the map connected
+		var protected map[road]struct{}
+		if c.Connected {
+			protected = gm.spanningTree()
+		}
+
+		for _, r := range gm.edges() {
+			if gm.roadsCount() <= target {
+				break
+			}
+			if _, ok := protected[r]; ok {
+				continue
+			}
+			if gm.degree(r.city) > c.MinDegree && gm.degree(gm.roads[r.city][r.direction]) > c.MinDegree {
+				gm.removeRoad(r)
+			}
+		}
+
+		if gm.roadsCount() != target {
+			return fmt.Errorf("average degree %.2f cannot be reached", c.AverageDegree)
+		}
+	}
+
+	if c.Connected {
+		components := gm.components()
+		for i := range gm.cities {
+			if components.Find(i) != components.Find(0) {
+				return fmt.Errorf("map cannot be connected")
+			}
+		}
+	}
+
+	for i := range gm.cities {
+		if gm.degree(i) < c.MinDegree {
+			return fmt.Errorf("minimal degree %d cannot be reached", c.MinDegree)
+		}
+	}
+
+	return nil
+}
+
+// degree returns a number of roads leading out of a city.
+func (gm *GridMap) degree(i int) int {
+	result := 0
+	for _, target := range gm.roads[i] {
+		if target != noCity {
+			result++
+		}
+	}
+	return result
+}
+
+// roadsCount returns a number of roads connecting cities.
+func (gm *GridMap) roadsCount() int {
+	result := 0
+	for i := range gm.roads {
+		result += gm.degree(i)
+	}
+	return result / 2
+}
+
+// roadsOf returns roads leading out of a city in a random order.
+func (gm *GridMap) roadsOf(i int) []road {
+	var result []road
+	for d, target := range gm.roads[i] {
+		if target != noCity {
+			result = append(result, road{city: i, direction: direction(d)})
+		}
+	}
+
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+
+	return result
+}
+
+// missingRoads returns possible roads leading south, east or southward diagonally which are not present in the map in a random order.
+func (gm *GridMap) missingRoads(possibleRoads []neighbors) []road {
+	var result []road
+	for i, neighbors := range possibleRoads {
+		for _, d := range forwardDirections {
+			if neighbors[d] != noCity && gm.roads[i][d] == noCity {
+				result = append(result, road{city: i, direction: d})
+			}
+		}
+	}
+
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+
+	return result
+}
+
+// addRoad restores a possible road along with the road leading back.
+func (gm *GridMap) addRoad(r road, possibleRoads []neighbors) {
+	target := possibleRoads[r.city][r.direction]
+	gm.roads[r.city][r.direction] = target
+	gm.roads[target][r.direction.opposite()] = r.city
+}
+
+// components returns connected parts of the map.
+func (gm *GridMap) components() *util.UnionFind {
+	uf := util.NewUnionFind(len(gm.cities))
+	for i, neighbors := range gm.roads {
+		for _, target := range neighbors {
+			if target != noCity {
+				uf.Union(i, target)
+			}
+		}
+	}
+	return uf
+}
+
+// spanningTree returns roads of a random spanning forest of the map.
+func (gm *GridMap) spanningTree() map[road]struct{} {
+	uf := util.NewUnionFind(len(gm.cities))
+	result := make(map[road]struct{})
+
+	for _, r := range gm.edges() {
+		if uf.Union(r.city, gm.roads[r.city][r.direction]) {
+			result[r] = struct{}{}
+		}
+	}
+
+	return result
+}
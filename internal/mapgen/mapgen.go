@@ -1,34 +1,91 @@
 package mapgen
 
 import (
-	"bytes"
-	_ "embed"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strconv"
 	"strings"
-	"text/template"
-)
-
-//go:embed city-names.txt
-var cityNames string
 
-//go:embed grid.dot.tmpl
-var dotGraphTemplate string
+	"github.com/maruqu/alien-invasion/internal/render"
+	"github.com/maruqu/alien-invasion/internal/simulation"
+)
 
 // GridMap stores a generated map.
-// Two internal representations are used to store information about city coordinates.
+// Cities are referenced in the roads by their indexes.
 type GridMap struct {
-	grid     [][]string
-	worldMap map[string]neighbors
+	height int
+	width  int
+	cities []city
+	roads  []neighbors
+	hex    bool
+}
+
+// Options configure generation of a map.
+type Options struct {
+	// Topology selects how roads between cities are created, TopologyGrid by default.
+	Topology Topology
+
+	// Sparsity is a fraction of roads removed by TopologySparse.
+	Sparsity float64
+
+	// Islands is a number of islands created by TopologyIslands.
+	Islands int
+
+	// Constraints which the generated roads have to satisfy.
+	Constraints Constraints
+
+	// Names is a source of city names, the embedded list of city names by default.
+	Names []string
+
+	// Naming selects how city names are picked from the source, NamingFirst by default.
+	Naming Naming
+
+	// Mask defines which cells may hold cities and which block roads, all cells are land by default.
+	// The mask has to match the grid size.
+	Mask *Mask
+
+	// Diagonals adds roads between the closest cities on the same diagonal (8-connected grid).
+	Diagonals bool
+}
+
+// noCity marks an empty cell of the grid or a missing road.
+const noCity = -1
+
+type direction int
+
+const (
+	north direction = iota
+	south
+	east
+	west
+	northEast
+	northWest
+	southEast
+	southWest
+
+	directionsCount
+)
+
+// opposite returns a direction of the road leading back.
+func (d direction) opposite() direction {
+	return [directionsCount]direction{south, north, west, east, southWest, southEast, northWest, northEast}[d]
 }
 
-type neighbors struct {
-	north *city
-	south *city
-	east  *city
-	west  *city
+// directions returns a number of directions roads may lead in.
+func (o Options) directions() int {
+	switch {
+	case o.Topology == TopologyHex:
+		return 6
+	case o.Diagonals:
+		return int(directionsCount)
+	}
+	return int(northEast)
 }
 
+// neighbors stores indexes of cities connected by roads in each direction.
+type neighbors [directionsCount]int
+
 type city struct {
 	name        string
 	coordinates coordinates
@@ -36,12 +93,34 @@ type city struct {
 
 type coordinates [2]int
 
+// diagonalColumn returns a column of the cell in which neighbors on a diagonal differ by one.
+// Odd rows of a hex grid are shifted by half a cell to the east, so hex columns are doubled.
+func (c coordinates) diagonalColumn(hex bool) int {
+	if hex {
+		return 2*c[1] + c[0]%2
+	}
+	return c[1]
+}
+
+// cellColumn returns a column of the cell in a provided row with a provided diagonal column.
+func cellColumn(row, diagonalColumn int, hex bool) int {
+	if hex {
+		return (diagonalColumn - row%2) / 2
+	}
+	return diagonalColumn
+}
+
 // NewGrid returns initialized GridMap structure containing a world map generated using provided parameters.
 // The process of generation is following:
 // 1. A grid of size height x width is created.
-// 2. Provided number of cities is randomly placed on the grid.
-// 3. If two cities are in the same row or column and there are no other cities between them, a road is created.
-func NewGridMap(height, width, citiesCount int) (*GridMap, error) {
+// 2. Provided number of cities is randomly placed on the grid (only on land cells if a mask is provided).
+// 3. If two cities are in the same row or column and there are no other cities
+// or impassable cells between them, a road is created. With diagonals enabled,
+// the same applies to cities on the same diagonal.
+// 4. Roads are adjusted according to the selected topology.
+// 5. Roads are added or removed to satisfy the constraints. If it is not possible,
+// cities are placed again up to maxAttempts times.
+func NewGridMap(height, width, citiesCount int, options Options) (*GridMap, error) {
 	if height*width < citiesCount {
 		return nil, fmt.Errorf(
 			"error creating grid: too many cities (%d) for provided map dimensions (%dx%d)",
@@ -49,42 +128,89 @@ func NewGridMap(height, width, citiesCount int) (*GridMap, error) {
 		)
 	}
 
-	grid, err := generateGrid(height, width, citiesCount)
-	if err != nil {
+	var land []int
+	if options.Mask != nil {
+		if options.Mask.height != height || options.Mask.width != width {
+			return nil, fmt.Errorf(
+				"error creating grid: mask size (%dx%d) does not match provided map dimensions (%dx%d)",
+				options.Mask.height, options.Mask.width, height, width,
+			)
+		}
+
+		land = options.Mask.land()
+		if len(land) < citiesCount {
+			return nil, fmt.Errorf(
+				"error creating grid: too many cities (%d) for land cells of the mask (%d)",
+				citiesCount, len(land),
+			)
+		}
+	}
+
+	if err := options.validate(); err != nil {
 		return nil, err
 	}
 
-	worldMap := generateWorldMap(grid)
+	if err := options.Constraints.validate(citiesCount, options.directions()); err != nil {
+		return nil, err
+	}
 
-	return &GridMap{
-		grid:     grid,
-		worldMap: worldMap,
-	}, nil
-}
+	names, err := getCityNames(citiesCount, options)
+	if err != nil {
+		return nil, err
+	}
 
-func (gm *GridMap) String() string {
-	var sb strings.Builder
+	for attempt := 1; ; attempt++ {
+		cities := placeCities(height, width, names, land)
 
-	for city, neighbors := range gm.worldMap {
-		sb.WriteString(city)
+		// all possible roads are kept to be restored if required by the constraints
+		possibleRoads := generateRoads(height, width, cities, options)
 
-		if neighbors.north != nil {
-			sb.WriteString(fmt.Sprintf(" north=%s", neighbors.north.name))
+		gm := &GridMap{
+			height: height,
+			width:  width,
+			cities: cities,
+			roads:  append([]neighbors(nil), possibleRoads...),
+			hex:    options.Topology == TopologyHex,
 		}
 
-		if neighbors.south != nil {
-			sb.WriteString(fmt.Sprintf(" south=%s", neighbors.south.name))
-		}
+		gm.applyTopology(options)
 
-		if neighbors.east != nil {
-			sb.WriteString(fmt.Sprintf(" east=%s", neighbors.east.name))
+		err = gm.applyConstraints(options.Constraints, possibleRoads)
+		if err == nil {
+			return gm, nil
 		}
 
-		if neighbors.west != nil {
-			sb.WriteString(fmt.Sprintf(" west=%s", neighbors.west.name))
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("constraints not satisfied after %d attempts: %w", maxAttempts, err)
 		}
+	}
+}
 
-		sb.WriteString("\n")
+// String returns the map in the map file format.
+// Every city name is followed by its coordinates on the grid (name@x,y).
+// Hex grids store doubled columns, so odd rows are shifted by half a cell.
+// The output is written directly from the grid, as WorldMap is too slow for maps with millions of cities.
+func (gm *GridMap) String() string {
+	var sb strings.Builder
+
+	for i, city := range gm.cities {
+		sb.WriteString(city.name)
+		sb.WriteByte('@')
+		sb.WriteString(strconv.Itoa(city.coordinates.diagonalColumn(gm.hex)))
+		sb.WriteByte(',')
+		sb.WriteString(strconv.Itoa(city.coordinates[0]))
+
+		// directions of the grid follow the order of the map file format
+		for d, target := range gm.roads[i] {
+			if target == noCity {
+				continue
+			}
+			sb.WriteByte(' ')
+			sb.WriteString(string(simulation.Directions[d]))
+			sb.WriteByte('=')
+			sb.WriteString(gm.cities[target].name)
+		}
+		sb.WriteByte('\n')
 	}
 
 	return sb.String()
@@ -92,234 +218,222 @@ func (gm *GridMap) String() string {
 
 // DotGraph generates a dot format graph representation of world map.
 // Dot language is used by Graphviz (https://graphviz.org).
-// TODO: refactor
 func (gm *GridMap) DotGraph() (string, error) {
-	var sb strings.Builder
-
-	// generate vertical grid structure
-	column := make([]string, len(gm.grid))
-	for i := 0; i < len(gm.grid[0]); i++ {
-		for j := 0; j < len(gm.grid); j++ {
-			column[j] = fmt.Sprintf("N%d_%d", j, i)
-		}
-
-		sb.WriteString(strings.Join(column, " -- ") + "\n")
-	}
+	worldMap := gm.WorldMap()
+	return render.DotGraph(worldMap, worldMap)
+}
 
-	verticalEdges := sb.String()
-	sb.Reset()
+// ASCII renders the world map as box art with cities placed on their grid positions.
+func (gm *GridMap) ASCII() string {
+	worldMap := gm.WorldMap()
+	return render.ASCII(worldMap, worldMap)
+}
 
-	// generate horizontal grid structure
-	row := make([]string, len(gm.grid[0]))
-	for i := 0; i < len(gm.grid); i++ {
-		for j := 0; j < len(gm.grid[0]); j++ {
-			row[j] = fmt.Sprintf("N%d_%d", i, j)
+// WorldMap returns the generated map with coordinates of the cities.
+func (gm *GridMap) WorldMap() simulation.WorldMap {
+	worldMap := make(simulation.WorldMap, len(gm.cities))
+
+	for i, city := range gm.cities {
+		roads := gm.roads[i]
+		worldMap[simulation.City(city.name)] = simulation.Neighbors{
+			North:       gm.cityName(roads[north]),
+			South:       gm.cityName(roads[south]),
+			East:        gm.cityName(roads[east]),
+			West:        gm.cityName(roads[west]),
+			NorthEast:   gm.cityName(roads[northEast]),
+			NorthWest:   gm.cityName(roads[northWest]),
+			SouthEast:   gm.cityName(roads[southEast]),
+			SouthWest:   gm.cityName(roads[southWest]),
+			Coordinates: &simulation.Coordinates{X: city.coordinates.diagonalColumn(gm.hex), Y: city.coordinates[0]},
 		}
-
-		sb.WriteString(fmt.Sprintf("rank=same {%s}\n", strings.Join(row, " -- ")))
 	}
 
-	horizontalEdges := sb.String()
-	sb.Reset()
+	return worldMap
+}
 
-	// hide nodes without cities
-	for i := 0; i < len(gm.grid); i++ {
-		for j := 0; j < len(gm.grid[0]); j++ {
-			if gm.grid[i][j] == "" {
-				sb.WriteString(fmt.Sprintf("N%d_%d [style=invis]\n", i, j))
-			}
-		}
+// cityName returns a name of the city with a provided index or an empty name for a missing city.
+func (gm *GridMap) cityName(idx int) simulation.City {
+	if idx == noCity {
+		return ""
 	}
+	return simulation.City(gm.cities[idx].name)
+}
 
-	hiddenNodes := sb.String()
-	sb.Reset()
-
-	// draw roads between cities
-	connectedCities := make(map[string]struct{})
-	for i := 0; i < len(gm.grid); i++ {
-		for j := 0; j < len(gm.grid[0]); j++ {
-			if gm.grid[i][j] != "" {
-				neighbors := gm.worldMap[gm.grid[i][j]]
+// placeCities returns cities with provided names placed in random cells of a height x width grid.
+// If land is provided, only the cells with the listed indexes are used.
+// Cells are picked using a partial Fisher-Yates shuffle of all the cells of the grid.
+// For sparse grids only the shuffled cells are stored.
+func placeCities(height, width int, names []string, land []int) []city {
+	cellsCount := height * width
+	if land != nil {
+		cellsCount = len(land)
+	}
 
-				if neighbors.north != nil {
-					if _, ok := connectedCities[neighbors.north.name]; !ok {
-						sb.WriteString(fmt.Sprintf("N%d_%d -- N%d_%d [style=solid]\n", i, j, neighbors.north.coordinates[0], neighbors.north.coordinates[1]))
-					}
-				}
-				if neighbors.south != nil {
-					if _, ok := connectedCities[neighbors.south.name]; !ok {
-						sb.WriteString(fmt.Sprintf("N%d_%d -- N%d_%d [style=solid]\n", i, j, neighbors.south.coordinates[0], neighbors.south.coordinates[1]))
-					}
-				}
-				if neighbors.east != nil {
-					if _, ok := connectedCities[neighbors.east.name]; !ok {
-						sb.WriteString(fmt.Sprintf("N%d_%d -- N%d_%d [style=solid]\n", i, j, neighbors.east.coordinates[0], neighbors.east.coordinates[1]))
-					}
-				}
-				if neighbors.west != nil {
-					if _, ok := connectedCities[neighbors.west.name]; !ok {
-						sb.WriteString(fmt.Sprintf("N%d_%d -- N%d_%d [style=solid]\n", i, j, neighbors.west.coordinates[0], neighbors.west.coordinates[1]))
-					}
-				}
+	var cell func(i int) int
+	var swap func(i, j int)
 
-				connectedCities[gm.grid[i][j]] = struct{}{}
-			}
+	if cellsCount <= 4*len(names) {
+		cells := make([]int, cellsCount)
+		for i := range cells {
+			cells[i] = i
 		}
-	}
 
-	roads := sb.String()
-	sb.Reset()
+		cell = func(i int) int { return cells[i] }
+		swap = func(i, j int) { cells[i], cells[j] = cells[j], cells[i] }
+	} else {
+		// cells which are not present in the map were never moved
+		cells := make(map[int]int, 2*len(names))
 
-	// label nodes with city names
-	for i := 0; i < len(gm.grid); i++ {
-		for j := 0; j < len(gm.grid[0]); j++ {
-			if gm.grid[i][j] != "" {
-				sb.WriteString(fmt.Sprintf("N%d_%d [label=\"%s\"]\n", i, j, gm.grid[i][j]))
+		cell = func(i int) int {
+			if c, ok := cells[i]; ok {
+				return c
 			}
+			return i
 		}
+		swap = func(i, j int) { cells[i], cells[j] = cell(j), cell(i) }
 	}
 
-	labels := sb.String()
+	cities := make([]city, len(names))
+	for i, name := range names {
+		swap(i, i+rand.Intn(cellsCount-i))
 
-	// insert generated nodes and edges into the template
-
-	tmpl, err := template.New("").Parse(dotGraphTemplate)
-	if err != nil {
-		return "", fmt.Errorf("error parsing template: %w", err)
-	}
-
-	var result bytes.Buffer
-	err = tmpl.Execute(&result, map[string]string{
-		"hiddenNodes":     hiddenNodes,
-		"verticalEdges":   verticalEdges,
-		"horizontalEdges": horizontalEdges,
-		"roads":           roads,
-		"labels":          labels,
-	})
+		c := cell(i)
+		if land != nil {
+			c = land[c]
+		}
 
-	if err != nil {
-		return "", fmt.Errorf("error generating graph from template: %s", err)
+		cities[i] = city{
+			name:        name,
+			coordinates: coordinates{c / width, c % width},
+		}
 	}
 
-	// TODO destroyed cities placeholder
-
-	return result.String(), nil
+	return cities
 }
 
-// generateGrid returns a grid of size height x width with cities placed in random places.
-func generateGrid(height, width, citiesCount int) ([][]string, error) {
-	grid := make([][]string, height)
-	for i := range grid {
-		grid[i] = make([]string, width)
+// generateRoads finds roads leading out of every city placed on a height x width grid.
+// Roads connect the closest cities in the same row or column.
+// With the torus topology the grid wraps around its edges. With diagonals enabled the closest
+// cities on the same diagonal are connected as well (diagonal roads never wrap).
+// The hex topology connects the closest cities in the same row and on both hex diagonals.
+// Roads are not created across impassable cells of the mask.
+func generateRoads(height, width int, cities []city, options Options) []neighbors {
+	wrap, hex, mask := options.Topology == TopologyTorus, options.Topology == TopologyHex, options.Mask
+
+	roads := make([]neighbors, len(cities))
+	for i := range roads {
+		for d := range roads[i] {
+			roads[i][d] = noCity
+		}
 	}
 
-	// place cities on the grid
+	type line struct {
+		lines              [][]int
+		forward, backward  direction
+		length             int
+		positionCoordinate int
+	}
 
-	cities, err := getCityNames(citiesCount)
-	if err != nil {
-		return nil, err
+	// connect consecutive cities in every row and column, hex grids have no columns
+	lines := []line{{sortedLines(cities, height, 0, 1), east, west, width, 1}}
+	if !hex {
+		lines = append(lines, line{sortedLines(cities, width, 1, 0), south, north, height, 0})
 	}
 
-	for _, city := range cities {
-		// Pick a random location until an empty location is found.
-		// This is not the most efficient method and can take many iterations when
-		// there is a small number of empty spots left.
-		for {
-			h, w := rand.Intn(height), rand.Intn(width)
+	for _, line := range lines {
+		position := func(idx int) int {
+			return cities[idx].coordinates[line.positionCoordinate]
+		}
 
-			// ensure that another city is not already placed here
-			if grid[h][w] != "" {
-				continue
+		for l, indexes := range line.lines {
+			for k := 1; k < len(indexes); k++ {
+				if mask.blocked(l, position(indexes[k-1]), position(indexes[k]), line.positionCoordinate) {
+					continue
+				}
+
+				roads[indexes[k-1]][line.forward] = indexes[k]
+				roads[indexes[k]][line.backward] = indexes[k-1]
 			}
 
-			grid[h][w] = city
-			break
+			if wrap && len(indexes) > 1 {
+				first, last := indexes[0], indexes[len(indexes)-1]
+				if mask.blocked(l, position(last), position(first)+line.length, line.positionCoordinate) {
+					continue
+				}
+
+				roads[last][line.forward] = first
+				roads[first][line.backward] = last
+			}
 		}
 	}
 
-	return grid, nil
-}
+	if !options.Diagonals && !hex {
+		return roads
+	}
 
-// generateWorldMap creates a mapping of city names to its neighbors based on the provided grid.
-func generateWorldMap(grid [][]string) map[string]neighbors {
-	worldMap := make(map[string]neighbors)
+	// connect consecutive cities on every diagonal, step is a change of the column per row
+	for _, diagonal := range []struct {
+		forward, backward direction
+		step              int
+	}{
+		{southEast, northWest, 1},
+		{southWest, northEast, -1},
+	} {
+		for _, indexes := range sortedDiagonals(cities, diagonal.step, hex) {
+			for k := 1; k < len(indexes); k++ {
+				if mask.blockedDiagonal(cities[indexes[k-1]].coordinates, cities[indexes[k]].coordinates, diagonal.step, hex) {
+					continue
+				}
 
-	// get all possible roads from each city
-	for h := 0; h < len(grid); h++ {
-		for w := 0; w < len(grid[0]); w++ {
-			if grid[h][w] != "" {
-				neighbors := findNeighbors(h, w, grid)
-				worldMap[grid[h][w]] = neighbors
+				roads[indexes[k-1]][diagonal.forward] = indexes[k]
+				roads[indexes[k]][diagonal.backward] = indexes[k-1]
 			}
 		}
 	}
 
-	return worldMap
+	return roads
 }
 
-// getCityNames returns a slice of cities with a provided count.
-func getCityNames(count int) ([]string, error) {
-	names := strings.Split(strings.TrimSpace(cityNames), "\n")
-
-	if len(names) < count {
-		return nil, fmt.Errorf("maximum number of cities exceeded (%d)", len(names))
+// sortedDiagonals groups indexes of the cities lying on the same diagonal
+// and sorts every group by the row. Diagonals are ordered by their first column.
+func sortedDiagonals(cities []city, step int, hex bool) [][]int {
+	groups := make(map[int][]int)
+	var keys []int
+	for i, city := range cities {
+		key := city.coordinates.diagonalColumn(hex) - step*city.coordinates[0]
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
 	}
-
-	result := make([]string, count)
-	for i := 0; i < count; i++ {
-		result[i] = names[i]
+	sort.Ints(keys)
+
+	lines := make([][]int, len(keys))
+	for k, key := range keys {
+		indexes := groups[key]
+		sort.Slice(indexes, func(i, j int) bool {
+			return cities[indexes[i]].coordinates[0] < cities[indexes[j]].coordinates[0]
+		})
+		lines[k] = indexes
 	}
 
-	return result, nil
+	return lines
 }
 
-// findNeighbors finds closest cities in the same row or column of the grid.
-func findNeighbors(h, w int, grid [][]string) neighbors {
-	result := neighbors{}
-
-	// north
-	for i := h - 1; i >= 0; i-- {
-		if grid[i][w] != "" {
-			result.north = &city{
-				name:        grid[i][w],
-				coordinates: coordinates{i, w},
-			}
-			break
-		}
-	}
-
-	// south
-	for i := h + 1; i < len(grid); i++ {
-		if grid[i][w] != "" {
-			result.south = &city{
-				name:        grid[i][w],
-				coordinates: coordinates{i, w},
-			}
-			break
-		}
+// sortedLines groups indexes of the cities by one of their coordinates (line)
+// and sorts every group by the other coordinate.
+func sortedLines(cities []city, linesCount, lineCoordinate, positionCoordinate int) [][]int {
+	lines := make([][]int, linesCount)
+	for i, city := range cities {
+		line := city.coordinates[lineCoordinate]
+		lines[line] = append(lines[line], i)
 	}
 
-	// east
-	for i := w + 1; i < len(grid[0]); i++ {
-		if grid[h][i] != "" {
-			result.east = &city{
-				name:        grid[h][i],
-				coordinates: coordinates{h, i},
-			}
-			break
-		}
-	}
-
-	// west
-	for i := w - 1; i >= 0; i-- {
-		if grid[h][i] != "" {
-			result.west = &city{
-				name:        grid[h][i],
-				coordinates: coordinates{h, i},
-			}
-			break
-		}
+	for _, indexes := range lines {
+		sort.Slice(indexes, func(i, j int) bool {
+			return cities[indexes[i]].coordinates[positionCoordinate] < cities[indexes[j]].coordinates[positionCoordinate]
+		})
 	}
 
-	return result
+	return lines
 }
@@ -0,0 +1,316 @@
+package mapgen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maruqu/alien-invasion/internal/render"
+	"github.com/maruqu/alien-invasion/internal/util"
+	"github.com/maruqu/alien-invasion/internal/world"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewGridMap(t *testing.T) {
+	for _, topology := range Topologies {
+		t.Run("roads lead both ways in "+string(topology)+" topology", func(t *testing.T) {
+			gm, err := NewGridMap(10, 10, 50, Options{Topology: topology, Sparsity: 0.5, Islands: 3})
+			require.NoError(t, err)
+
+			assert.Len(t, gm.cities, 50)
+			assertSymmetric(t, gm)
+		})
+	}
+
+	t.Run("maze has no cycles", func(t *testing.T) {
+		gm, err := NewGridMap(10, 10, 50, Options{Topology: TopologyMaze})
+		require.NoError(t, err)
+
+		uf := util.NewUnionFind(len(gm.cities))
+		for _, r := range gm.edges() {
+			assert.True(t, uf.Union(r.city, gm.roads[r.city][r.direction]))
+		}
+	})
+
+	t.Run("torus wraps around the grid", func(t *testing.T) {
+		cities := []city{
+			{name: "Anvik", coordinates: coordinates{0, 0}},
+			{name: "Hatch", coordinates: coordinates{0, 2}},
+		}
+		roads := generateRoads(1, 3, cities, Options{Topology: TopologyTorus})
+
+		assert.Equal(t, neighbors{noCity, noCity, 1, 1, noCity, noCity, noCity, noCity}, roads[0])
+		assert.Equal(t, neighbors{noCity, noCity, 0, 0, noCity, noCity, noCity, noCity}, roads[1])
+	})
+
+	t.Run("diagonal roads lead both ways", func(t *testing.T) {
+		gm, err := NewGridMap(10, 10, 50, Options{Diagonals: true})
+		require.NoError(t, err)
+		assert.NoError(t, gm.WorldMap().Validate())
+	})
+
+	t.Run("closest cities on diagonals connected", func(t *testing.T) {
+		cities := []city{
+			{name: "Anvik", coordinates: coordinates{0, 0}},
+			{name: "Hatch", coordinates: coordinates{2, 2}},
+			{name: "Fabens", coordinates: coordinates{1, 3}},
+		}
+		roads := generateRoads(3, 4, cities, Options{Diagonals: true})
+
+		assert.Equal(t, neighbors{noCity, noCity, noCity, noCity, noCity, noCity, 1, noCity}, roads[0])
+		assert.Equal(t, neighbors{noCity, noCity, noCity, noCity, 2, 0, noCity, noCity}, roads[1])
+		assert.Equal(t, neighbors{noCity, noCity, noCity, noCity, noCity, noCity, noCity, 1}, roads[2])
+	})
+
+	t.Run("hex grid connects six directions", func(t *testing.T) {
+		cities := []city{
+			{name: "Anvik", coordinates: coordinates{0, 0}},
+			{name: "Hatch", coordinates: coordinates{1, 0}},
+			{name: "Fabens", coordinates: coordinates{2, 0}},
+			{name: "Pinson", coordinates: coordinates{1, 1}},
+		}
+		roads := generateRoads(3, 2, cities, Options{Topology: TopologyHex})
+
+		// odd rows are shifted by half a cell, so Hatch lies southeast of Anvik
+		assert.Equal(t, neighbors{noCity, noCity, noCity, noCity, noCity, noCity, 1, noCity}, roads[0])
+		assert.Equal(t, neighbors{noCity, noCity, 3, noCity, noCity, 0, noCity, 2}, roads[1])
+		assert.Equal(t, neighbors{noCity, noCity, noCity, noCity, 1, noCity, noCity, noCity}, roads[2])
+		assert.Equal(t, neighbors{noCity, noCity, noCity, 1, noCity, noCity, noCity, noCity}, roads[3])
+	})
+
+	t.Run("hex grid stores doubled columns", func(t *testing.T) {
+		gm, err := NewGridMap(4, 4, 10, Options{Topology: TopologyHex})
+		require.NoError(t, err)
+		assert.True(t, render.IsHex(gm.WorldMap()))
+	})
+
+	t.Run("string parsed back to the world map", func(t *testing.T) {
+		for _, options := range []Options{{Diagonals: true}, {Topology: TopologyHex}} {
+			gm, err := NewGridMap(10, 10, 50, options)
+			require.NoError(t, err)
+
+			worldMap, err := world.Parse(strings.NewReader(gm.String()))
+			require.NoError(t, err)
+			assert.Equal(t, gm.WorldMap(), worldMap)
+		}
+	})
+
+	t.Run("unknown topology rejected", func(t *testing.T) {
+		_, err := NewGridMap(10, 10, 50, Options{Topology: "unknown"})
+		assert.Error(t, err)
+	})
+}
+
+func Test_Constraints(t *testing.T) {
+	t.Run("connected map with limited degrees", func(t *testing.T) {
+		constraints := Constraints{Connected: true, MinDegree: 1, MaxDegree: 3, AverageDegree: 2.2}
+
+		gm, err := NewGridMap(8, 8, 30, Options{Topology: TopologySparse, Sparsity: 0.5, Constraints: constraints})
+		require.NoError(t, err)
+
+		components := gm.components()
+		for i := range gm.cities {
+			assert.Equal(t, components.Find(0), components.Find(i))
+			assert.GreaterOrEqual(t, gm.degree(i), 1)
+			assert.LessOrEqual(t, gm.degree(i), 3)
+		}
+		assert.Equal(t, 33, gm.roadsCount())
+		assertSymmetric(t, gm)
+	})
+
+	t.Run("infeasible constraints rejected", func(t *testing.T) {
+		for _, constraints := range []Constraints{
+			{MinDegree: 3, MaxDegree: 2},
+			{MaxDegree: 5},
+			{Connected: true, MaxDegree: 1},
+			{Connected: true, AverageDegree: 1},
+			{AverageDegree: 3, MaxDegree: 2},
+		} {
+			_, err := NewGridMap(8, 8, 30, Options{Constraints: constraints})
+			assert.Error(t, err, "%+v", constraints)
+		}
+	})
+
+	t.Run("unreachable constraints reported", func(t *testing.T) {
+		// cities placed on a single row can have at most 2 roads
+		_, err := NewGridMap(1, 10, 5, Options{Constraints: Constraints{MinDegree: 3}})
+		assert.Error(t, err)
+	})
+}
+
+func Test_Mask(t *testing.T) {
+	mask, err := ParseMask(strings.NewReader(`
+.#.
+.~.
+ ..
+`[1:]))
+	require.NoError(t, err)
+	require.Equal(t, 3, mask.Height())
+	require.Equal(t, 3, mask.Width())
+
+	t.Run("cities placed only on land", func(t *testing.T) {
+		gm, err := NewGridMap(3, 3, 6, Options{Mask: mask})
+		require.NoError(t, err)
+
+		for _, city := range gm.cities {
+			assert.Equal(t, TerrainLand, mask.Terrain(city.coordinates[0], city.coordinates[1]))
+		}
+	})
+
+	t.Run("impassable terrain blocks roads", func(t *testing.T) {
+		gm, err := NewGridMap(3, 3, 6, Options{Mask: mask})
+		require.NoError(t, err)
+
+		// only the bottom row and the side columns are connected
+		assert.Equal(t, 4, gm.roadsCount())
+		for i, city := range gm.cities {
+			if city.coordinates[0] < 2 {
+				assert.Equal(t, noCity, gm.roads[i][east])
+				assert.Equal(t, noCity, gm.roads[i][west])
+			}
+		}
+	})
+
+	t.Run("impassable terrain blocks wrapping roads", func(t *testing.T) {
+		cities := []city{
+			{name: "Anvik", coordinates: coordinates{0, 0}},
+			{name: "Hatch", coordinates: coordinates{0, 1}},
+		}
+		mask, err := ParseMask(strings.NewReader("..#\n"))
+		require.NoError(t, err)
+
+		roads := generateRoads(1, 3, cities, Options{Topology: TopologyTorus, Mask: mask})
+
+		assert.Equal(t, neighbors{noCity, noCity, 1, noCity, noCity, noCity, noCity, noCity}, roads[0])
+		assert.Equal(t, neighbors{noCity, noCity, noCity, 0, noCity, noCity, noCity, noCity}, roads[1])
+	})
+
+	t.Run("too many cities for the mask rejected", func(t *testing.T) {
+		_, err := NewGridMap(3, 3, 7, Options{Mask: mask})
+		assert.Error(t, err)
+	})
+
+	t.Run("mask size has to match the grid", func(t *testing.T) {
+		_, err := NewGridMap(4, 3, 6, Options{Mask: mask})
+		assert.Error(t, err)
+	})
+}
+
+func Test_CityNames(t *testing.T) {
+	source := []string{"Anvik", "Hatch", "Fabens", "Pinson"}
+
+	t.Run("first names taken in order", func(t *testing.T) {
+		names, err := getCityNames(3, Options{Names: source})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Anvik", "Hatch", "Fabens"}, names)
+	})
+
+	t.Run("shuffled names taken from the source", func(t *testing.T) {
+		names, err := getCityNames(4, Options{Names: source, Naming: NamingShuffle})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, source, names)
+	})
+
+	t.Run("generated names are unique", func(t *testing.T) {
+		names, err := getCityNames(100, Options{Names: source, Naming: NamingGenerate})
+		require.NoError(t, err)
+		assert.NoError(t, validateNames(names))
+	})
+
+	t.Run("too few names rejected", func(t *testing.T) {
+		_, err := getCityNames(5, Options{Names: source})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid names rejected", func(t *testing.T) {
+		for _, names := range [][]string{
+			{"Anvik", "Anvik"},
+			{"New York"},
+			{"Foo=Bar"},
+			{"Foo@1,2"},
+			{"Foo:2"},
+			{"Foo$2"},
+			{">Foo"},
+		} {
+			_, err := getCityNames(1, Options{Names: names})
+			assert.Error(t, err, "%v", names)
+		}
+	})
+}
+
+var benchmarkGrids = []struct {
+	height, width, cities int
+}{
+	{1000, 1000, 1000000},   // full grid
+	{10000, 10000, 1000000}, // sparse grid
+}
+
+func BenchmarkNewGridMap(b *testing.B) {
+	for _, grid := range []struct {
+		height, width, cities int
+	}{
+		{100, 100, 10000},
+		{10000, 10000, 10000},
+	} {
+		b.Run(fmt.Sprintf("%dx%d/%d", grid.height, grid.width, grid.cities), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := NewGridMap(grid.height, grid.width, grid.cities, Options{})
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkString(b *testing.B) {
+	for _, grid := range benchmarkGrids {
+		// the embedded list of city names is too short for the benchmark grids
+		names := make([]string, grid.cities)
+		for i := range names {
+			names[i] = fmt.Sprintf("City%d", i)
+		}
+
+		b.Run(fmt.Sprintf("%dx%d/%d", grid.height, grid.width, grid.cities), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gm, err := NewGridMap(grid.height, grid.width, grid.cities, Options{Names: names})
+				require.NoError(b, err)
+				_ = gm.String()
+			}
+		})
+	}
+}
+
+func BenchmarkPlaceCities(b *testing.B) {
+	for _, grid := range benchmarkGrids {
+		names := make([]string, grid.cities)
+
+		b.Run(fmt.Sprintf("%dx%d/%d", grid.height, grid.width, grid.cities), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				placeCities(grid.height, grid.width, names, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateRoads(b *testing.B) {
+	for _, grid := range benchmarkGrids {
+		cities := placeCities(grid.height, grid.width, make([]string, grid.cities), nil)
+
+		b.Run(fmt.Sprintf("%dx%d/%d", grid.height, grid.width, grid.cities), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				generateRoads(grid.height, grid.width, cities, Options{})
+			}
+		})
+	}
+}
+
+// assertSymmetric checks that every road has a matching road leading back.
+func assertSymmetric(t *testing.T, gm *GridMap) {
+	for i, neighbors := range gm.roads {
+		for d, target := range neighbors {
+			if target != noCity {
+				assert.Equal(t, i, gm.roads[target][direction(d).opposite()])
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package mapgen
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Terrain is a kind of a grid cell defined by a mask.
+type Terrain byte
+
+const (
+	// TerrainBlank cells hold no cities, but do not block roads.
+	TerrainBlank Terrain = iota
+
+	// TerrainLand cells may hold cities.
+	TerrainLand
+
+	// TerrainImpassable cells hold no cities and block roads leading across them.
+	TerrainImpassable
+)
+
+// Mask defines terrain of every cell of the grid.
+type Mask struct {
+	height int
+	width  int
+	cells  []Terrain
+}
+
+// NewMask returns a mask of size height x width with all the cells blank.
+func NewMask(height, width int) *Mask {
+	return &Mask{
+		height: height,
+		width:  width,
+		cells:  make([]Terrain, height*width),
+	}
+}
+
+// LoadMask reads a mask from a PNG image or an ASCII text file.
+// Files with the .png extension are decoded with DecodeMask, other files are parsed with ParseMask.
+func LoadMask(path string) (*Mask, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		return DecodeMask(file)
+	}
+	return ParseMask(file)
+}
+
+// ParseMask reads a mask from ASCII text. Every line is a row of the grid:
+// '.' marks land, '#' and '~' mark impassable terrain, any other character marks a blank cell.
+// The grid is as wide as the longest line.
+func ParseMask(r io.Reader) (*Mask, error) {
+	var rows [][]rune
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rows = append(rows, []rune(scanner.Text()))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// trailing empty lines are not a part of the grid
+	for len(rows) > 0 && strings.TrimSpace(string(rows[len(rows)-1])) == "" {
+		rows = rows[:len(rows)-1]
+	}
+
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	m := NewMask(len(rows), width)
+	for h, row := range rows {
+		for w, char := range row {
+			switch char {
+			case '.':
+				m.Set(h, w, TerrainLand)
+			case '#', '~':
+				m.Set(h, w, TerrainImpassable)
+			}
+		}
+	}
+
+	return m, m.validate()
+}
+
+// DecodeMask reads a mask from a PNG image. Every pixel is a cell of the grid:
+// transparent pixels mark blank cells, dark pixels mark impassable terrain and light pixels mark land.
+func DecodeMask(r io.Reader) (*Mask, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding mask image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	m := NewMask(bounds.Dy(), bounds.Dx())
+
+	for h := 0; h < m.height; h++ {
+		for w := 0; w < m.width; w++ {
+			m.Set(h, w, pixelTerrain(img, bounds.Min.X+w, bounds.Min.Y+h))
+		}
+	}
+
+	return m, m.validate()
+}
+
+// pixelTerrain returns terrain of a cell represented by an image pixel.
+func pixelTerrain(img image.Image, x, y int) Terrain {
+	pixel := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	if pixel.A < 128 {
+		return TerrainBlank
+	}
+
+	if gray := color.GrayModel.Convert(pixel).(color.Gray); gray.Y < 128 {
+		return TerrainImpassable
+	}
+	return TerrainLand
+}
+
+// Height returns a number of rows of the mask.
+func (m *Mask) Height() int {
+	return m.height
+}
+
+// Width returns a number of columns of the mask.
+func (m *Mask) Width() int {
+	return m.width
+}
+
+// Set changes terrain of a cell in the row h and the column w.
+func (m *Mask) Set(h, w int, terrain Terrain) {
+	m.cells[h*m.width+w] = terrain
+}
+
+// Terrain returns terrain of a cell in the row h and the column w.
+func (m *Mask) Terrain(h, w int) Terrain {
+	return m.cells[h*m.width+w]
+}
+
+func (m *Mask) validate() error {
+	if m.height == 0 || m.width == 0 {
+		return fmt.Errorf("mask cannot be empty")
+	}
+	return nil
+}
+
+// land returns indexes of the cells which may hold cities.
+// Every cell of the grid may hold a city if no mask is provided (nil is returned).
+func (m *Mask) land() []int {
+	if m == nil {
+		return nil
+	}
+
+	result := make([]int, 0, len(m.cells))
+	for i, terrain := range m.cells {
+		if terrain == TerrainLand {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// blocked reports whether impassable terrain lies between two cells of the same row or column.
+// A line is a row if positionCoordinate is 1 (a column) and a column otherwise.
+// The to position may exceed the line length for roads wrapping around the grid.
+func (m *Mask) blocked(line, from, to, positionCoordinate int) bool {
+	if m == nil {
+		return false
+	}
+
+	length := m.height
+	if positionCoordinate == 1 {
+		length = m.width
+	}
+
+	for position := from + 1; position < to; position++ {
+		cell := coordinates{}
+		cell[1-positionCoordinate] = line
+		cell[positionCoordinate] = position % length
+
+		if m.Terrain(cell[0], cell[1]) == TerrainImpassable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockedDiagonal reports whether impassable terrain lies between two cells of the same diagonal.
+// The from cell lies above the to cell and step is a change of the diagonal column per row.
+func (m *Mask) blockedDiagonal(from, to coordinates, step int, hex bool) bool {
+	if m == nil {
+		return false
+	}
+
+	for k := 1; k < to[0]-from[0]; k++ {
+		row := from[0] + k
+		if m.Terrain(row, cellColumn(row, from.diagonalColumn(hex)+step*k, hex)) == TerrainImpassable {
+			return true
+		}
+	}
+
+	return false
+}
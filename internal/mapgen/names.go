@@ -0,0 +1,99 @@
+package mapgen
+
+import (
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/maruqu/alien-invasion/internal/namegen"
+)
+
+//go:embed city-names.txt
+var cityNames string
+
+// Naming selects how city names are picked from the source names.
+type Naming string
+
+const (
+	// NamingFirst takes the first names from the source.
+	NamingFirst Naming = "first"
+
+	// NamingShuffle takes random names from the source.
+	NamingShuffle Naming = "shuffle"
+
+	// NamingGenerate generates an unlimited number of new names resembling the source names.
+	NamingGenerate Naming = "generate"
+)
+
+// Namings lists all supported naming modes.
+var Namings = []Naming{
+	NamingFirst,
+	NamingShuffle,
+	NamingGenerate,
+}
+
+// getCityNames returns a slice of unique city names with a provided count.
+func getCityNames(count int, options Options) ([]string, error) {
+	names := options.Names
+	if names == nil {
+		names = strings.Split(strings.TrimSpace(cityNames), "\n")
+	}
+
+	if err := validateNames(names); err != nil {
+		return nil, err
+	}
+
+	switch options.Naming {
+	case "", NamingFirst, NamingShuffle:
+		if len(names) < count {
+			return nil, fmt.Errorf("maximum number of cities exceeded (%d)", len(names))
+		}
+
+		if options.Naming == NamingShuffle {
+			result := make([]string, count)
+			for i, idx := range rand.Perm(len(names))[:count] {
+				result[i] = names[idx]
+			}
+			return result, nil
+		}
+
+		return names[:count], nil
+	case NamingGenerate:
+		generator, err := namegen.New(names, rand.New(rand.NewSource(rand.Int63())))
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]string, count)
+		for i := range result {
+			result[i], err = generator.Next()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unknown naming: %s", options.Naming)
+	}
+}
+
+// validateNames ensures that city names are unique and can be written to a map file.
+// Besides the whitespace and the attribute separators, ':' and '$' are rejected since they
+// start a road length and a road cost in the map file, and a leading '>' since it marks a one-way road.
+func validateNames(names []string) error {
+	unique := make(map[string]struct{}, len(names))
+
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, " \t=@:$") || strings.HasPrefix(name, ">") {
+			return fmt.Errorf("invalid city name: %q", name)
+		}
+
+		if _, ok := unique[name]; ok {
+			return fmt.Errorf("duplicated city name: %s", name)
+		}
+		unique[name] = struct{}{}
+	}
+
+	return nil
+}
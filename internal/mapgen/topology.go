@@ -0,0 +1,230 @@
+package mapgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/maruqu/alien-invasion/internal/util"
+)
+
+// Topology selects how roads between cities placed on the grid are created.
+type Topology string
+
+const (
+	// TopologyGrid connects the closest cities in the same row or column.
+	TopologyGrid Topology = "grid"
+
+	// TopologyTorus connects cities like TopologyGrid, but the grid wraps around its edges.
+	TopologyTorus Topology = "torus"
+
+	// TopologyMaze keeps a random spanning tree of the grid roads,
+	// so there is exactly one path between any two connected cities.
+	TopologyMaze Topology = "maze"
+
+	// TopologySparse randomly removes a fraction of the grid roads.
+	TopologySparse Topology = "sparse"
+
+	// TopologyIslands splits cities into clustered islands connected by single bridges.
+	TopologyIslands Topology = "islands"
+
+	// TopologyCorridors keeps roads along the rows and joins consecutive rows
+	// at alternating ends, creating long winding corridors.
+	TopologyCorridors Topology = "corridors"
+
+	// TopologyHex places cities on a hexagonal grid with odd rows shifted by half a cell.
+	// The closest cities in six directions (east, west and the diagonals) are connected.
+	TopologyHex Topology = "hex"
+)
+
+// Topologies lists all supported topologies.
+var Topologies = []Topology{
+	TopologyGrid,
+	TopologyTorus,
+	TopologyMaze,
+	TopologySparse,
+	TopologyIslands,
+	TopologyCorridors,
+	TopologyHex,
+}
+
+// road identifies a road by an index of the city and a direction leading out of it.
+type road struct {
+	city      int
+	direction direction
+}
+
+func (o Options) validate() error {
+	switch o.Topology {
+	case "", TopologyGrid, TopologyTorus, TopologyMaze, TopologyCorridors:
+	case TopologyHex:
+		if o.Diagonals {
+			return fmt.Errorf("diagonals are not supported by the hex topology")
+		}
+	case TopologySparse:
+		if o.Sparsity < 0 || o.Sparsity > 1 {
+			return fmt.Errorf("sparsity must be between 0 and 1")
+		}
+	case TopologyIslands:
+		if o.Islands < 1 {
+			return fmt.Errorf("at least one island is required")
+		}
+	default:
+		return fmt.Errorf("unknown topology: %s", o.Topology)
+	}
+
+	return nil
+}
+
+// applyTopology removes roads of the grid according to the selected topology.
+func (gm *GridMap) applyTopology(options Options) {
+	switch options.Topology {
+	case TopologyMaze:
+		gm.removeCycles(gm.edges(), func(r road) (int, int) {
+			return r.city, gm.roads[r.city][r.direction]
+		}, len(gm.cities))
+	case TopologySparse:
+		for _, r := range gm.edges() {
+			if rand.Float64() < options.Sparsity {
+				gm.removeRoad(r)
+			}
+		}
+	case TopologyIslands:
+		gm.splitIslands(options.Islands)
+	case TopologyCorridors:
+		gm.joinRows()
+	}
+}
+
+// forwardDirections lists directions in which every road connecting two cities is followed exactly once.
+var forwardDirections = []direction{south, east, southEast, southWest}
+
+// edges returns all roads leading south, east or southward diagonally in a random order.
+// Every road connecting two cities is listed exactly once.
+func (gm *GridMap) edges() []road {
+	var result []road
+	for i, neighbors := range gm.roads {
+		for _, d := range forwardDirections {
+			if neighbors[d] != noCity {
+				result = append(result, road{city: i, direction: d})
+			}
+		}
+	}
+
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+
+	return result
+}
+
+// removeRoad removes a road along with the road leading back.
+func (gm *GridMap) removeRoad(r road) {
+	target := gm.roads[r.city][r.direction]
+	gm.roads[r.city][r.direction] = noCity
+
+	if target != noCity && gm.roads[target][r.direction.opposite()] == r.city {
+		gm.roads[target][r.direction.opposite()] = noCity
+	}
+}
+
+// removeCycles keeps only the roads joining separate groups (Kruskal's algorithm).
+// Groups connected by a road are identified by the provided function.
+func (gm *GridMap) removeCycles(roads []road, groups func(road) (int, int), groupsCount int) {
+	uf := util.NewUnionFind(groupsCount)
+
+	for _, r := range roads {
+		if !uf.Union(groups(r)) {
+			gm.removeRoad(r)
+		}
+	}
+}
+
+// splitIslands assigns cities to islands around random centers and removes roads between
+// islands except single bridges keeping the islands connected.
+func (gm *GridMap) splitIslands(count int) {
+	centers := make([]coordinates, count)
+	for i := range centers {
+		centers[i] = coordinates{rand.Intn(gm.height), rand.Intn(gm.width)}
+	}
+
+	island := make([]int, len(gm.cities))
+	for i, city := range gm.cities {
+		best := -1
+		for j, center := range centers {
+			dh, dw := city.coordinates[0]-center[0], city.coordinates[1]-center[1]
+			if distance := dh*dh + dw*dw; best == -1 || distance < best {
+				best = distance
+				island[i] = j
+			}
+		}
+	}
+
+	var bridges []road
+	for _, r := range gm.edges() {
+		if island[r.city] != island[gm.roads[r.city][r.direction]] {
+			bridges = append(bridges, r)
+		}
+	}
+
+	gm.removeCycles(bridges, func(r road) (int, int) {
+		return island[r.city], island[gm.roads[r.city][r.direction]]
+	}, count)
+}
+
+// joinRows removes vertical and diagonal roads except a single road between every pair
+// of consecutive rows. The joining roads are picked at alternating ends of the rows.
+func (gm *GridMap) joinRows() {
+	var rows []int
+	joins := make(map[int][]road)
+
+	for _, r := range gm.edges() {
+		if r.direction == southEast || r.direction == southWest {
+			gm.removeRoad(r)
+			continue
+		}
+		if r.direction != south {
+			continue
+		}
+
+		row := gm.cities[r.city].coordinates[0]
+		if _, ok := joins[row]; !ok {
+			rows = append(rows, row)
+		}
+		joins[row] = append(joins[row], r)
+	}
+
+	sort.Ints(rows)
+
+	for k, row := range rows {
+		candidates := joins[row]
+
+		// prefer roads leading to the closest row below
+		closest := gm.height
+		for _, r := range candidates {
+			if target := gm.cities[gm.roads[r.city][south]].coordinates[0]; target < closest {
+				closest = target
+			}
+		}
+
+		keep := -1
+		for i, r := range candidates {
+			if gm.cities[gm.roads[r.city][south]].coordinates[0] != closest {
+				continue
+			}
+
+			column := gm.cities[r.city].coordinates[1]
+			if keep == -1 ||
+				(k%2 == 0 && column > gm.cities[candidates[keep].city].coordinates[1]) ||
+				(k%2 == 1 && column < gm.cities[candidates[keep].city].coordinates[1]) {
+				keep = i
+			}
+		}
+
+		for i, r := range candidates {
+			if i != keep {
+				gm.removeRoad(r)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package namegen
+
+import (
+	"fmt"
+	"math/rand"
+	"unicode/utf8"
+)
+
+const (
+	// order is a number of preceding characters used to pick the next character.
+	order = 3
+
+	// maxTries is a number of attempts to generate a new name before generated names are joined.
+	maxTries = 100
+
+	// maxAttempts is a number of attempts to generate a name of an acceptable length before giving up.
+	maxAttempts = 10000
+
+	// start and end mark the beginning and the end of a name in the chain.
+	// They are not valid characters, so they never appear in the training names.
+	start rune = -1
+	end   rune = -2
+)
+
+// context holds the characters preceding the next character of a name.
+type context [order]rune
+
+// Generator creates an unlimited number of unique names resembling the names it was trained on.
+// A character level Markov chain is used.
+type Generator struct {
+	rnd *rand.Rand
+
+	// transitions maps preceding characters to characters following them in the training names.
+	// Characters are repeated according to their frequency.
+	transitions map[context][]rune
+
+	minLength int
+	maxLength int
+
+	used map[string]struct{}
+}
+
+// New returns a Generator trained on the provided names.
+func New(names []string, rnd *rand.Rand) (*Generator, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no names to train the generator on")
+	}
+
+	g := &Generator{
+		rnd:         rnd,
+		transitions: make(map[context][]rune),
+		used:        make(map[string]struct{}),
+	}
+
+	for _, name := range names {
+		length := utf8.RuneCountInString(name)
+		if g.minLength == 0 || length < g.minLength {
+			g.minLength = length
+		}
+		if length > g.maxLength {
+			g.maxLength = length
+		}
+
+		runes := append(append(startContext(), []rune(name)...), end)
+		for i := order; i < len(runes); i++ {
+			var c context
+			copy(c[:], runes[i-order:i])
+			g.transitions[c] = append(g.transitions[c], runes[i])
+		}
+	}
+
+	return g, nil
+}
+
+// Next returns a name which was not returned by the generator before.
+// If a new name cannot be found in a reasonable number of tries, generated names are joined with hyphens.
+func (g *Generator) Next() (string, error) {
+	prefix := ""
+	for {
+		var name string
+		for i := 0; i < maxTries; i++ {
+			generated, err := g.generate()
+			if err != nil {
+				return "", err
+			}
+
+			name = prefix + generated
+			if _, ok := g.used[name]; !ok {
+				g.used[name] = struct{}{}
+				return name, nil
+			}
+		}
+
+		prefix = name + "-"
+	}
+}
+
+// Use marks a name as used, so that it is never returned by the generator.
+func (g *Generator) Use(name string) {
+	g.used[name] = struct{}{}
+}
+
+// Release marks a name as unused, so that it can be returned by the generator again.
+func (g *Generator) Release(name string) {
+	delete(g.used, name)
+}
+
+// generate returns a random name with a length between the shortest and the longest training name.
+// An error is returned if no such name is generated in maxAttempts attempts.
+func (g *Generator) generate() (string, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		runes := startContext()
+
+		for {
+			var c context
+			copy(c[:], runes[len(runes)-order:])
+
+			candidates := g.transitions[c]
+			next := candidates[g.rnd.Intn(len(candidates))]
+			if next == end {
+				break
+			}
+			runes = append(runes, next)
+
+			if len(runes)-order > g.maxLength {
+				break
+			}
+		}
+
+		name := runes[order:]
+		if len(name) >= g.minLength && len(name) <= g.maxLength {
+			return string(name), nil
+		}
+	}
+
+	return "", fmt.Errorf("no name of %d-%d characters generated in %d attempts", g.minLength, g.maxLength, maxAttempts)
+}
+
+// startContext returns the context preceding the first character of a name.
+func startContext() []rune {
+	runes := make([]rune, order)
+	for i := range runes {
+		runes[i] = start
+	}
+	return runes
+}
@@ -0,0 +1,66 @@
+package namegen
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Generator(t *testing.T) {
+	t.Run("unique names generated beyond the training set size", func(t *testing.T) {
+		g, err := New([]string{"Pinson", "Fabens", "Talihina"}, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+
+		names := make(map[string]struct{})
+		for i := 0; i < 100; i++ {
+			name, err := g.Next()
+			require.NoError(t, err)
+
+			assert.NotContains(t, names, name)
+			assert.False(t, strings.ContainsAny(name, " ^$"))
+			names[name] = struct{}{}
+		}
+	})
+
+	t.Run("used names skipped", func(t *testing.T) {
+		g, err := New([]string{"Pinson"}, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+
+		g.Use("Pinson")
+
+		name, err := g.Next()
+		require.NoError(t, err)
+		assert.NotEqual(t, "Pinson", name)
+	})
+
+	t.Run("released names returned again", func(t *testing.T) {
+		g, err := New([]string{"Pinson"}, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+
+		g.Use("Pinson")
+		g.Release("Pinson")
+
+		name, err := g.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "Pinson", name)
+	})
+
+	t.Run("names with special characters generated", func(t *testing.T) {
+		g, err := New([]string{"ab$cd", "ef^gh"}, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			name, err := g.Next()
+			require.NoError(t, err)
+			assert.True(t, strings.ContainsAny(name, "$^"))
+		}
+	})
+
+	t.Run("training names required", func(t *testing.T) {
+		_, err := New(nil, rand.New(rand.NewSource(1)))
+		assert.Error(t, err)
+	})
+}
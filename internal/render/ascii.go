@@ -0,0 +1,327 @@
+package render
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+)
+
+const (
+	// horizontalGap is the number of characters between two neighboring grid cells.
+	horizontalGap = 3
+
+	roadHorizontal = '─'
+	roadVertical   = '│'
+	roadCrossing   = '┼'
+	roadDiagonal   = '╲'
+	roadAntiDiag   = '╱'
+	roadDiagCross  = '╳'
+
+	arrowNorth = '▲'
+	arrowSouth = '▼'
+	arrowEast  = '►'
+	arrowWest  = '◄'
+
+	arrowNorthEast = '↗'
+	arrowNorthWest = '↖'
+	arrowSouthEast = '↘'
+	arrowSouthWest = '↙'
+)
+
+// ASCII renders cities of the initial world map placed on a grid (see NewLayout).
+// Cities are drawn as labeled cells and roads as lines between them.
+// Cities missing from the result map are marked as destroyed and only roads present
+// in the result map are drawn. Roads which do not lead straight in their direction
+// on the grid (e.g. wrapping around the grid) are skipped. One-way roads end with an arrow.
+func ASCII(initial, result simulation.WorldMap) string {
+	cities := sortedCities(initial)
+	if len(cities) == 0 {
+		return ""
+	}
+
+	layout := NewLayout(initial)
+
+	labels := make(map[simulation.City]string, len(cities))
+	cellWidth, maxX, maxY := 0, 0, 0
+	for _, city := range cities {
+		label := "[" + string(city) + "]"
+		if _, ok := result[city]; !ok {
+			label = "#" + string(city) + "#"
+		}
+		labels[city] = label
+
+		if n := utf8.RuneCountInString(label); n > cellWidth {
+			cellWidth = n
+		}
+
+		position := layout[city]
+		if position.X > maxX {
+			maxX = position.X
+		}
+		if position.Y > maxY {
+			maxY = position.Y
+		}
+	}
+
+	columnStart := func(x int) int {
+		return x * (cellWidth + horizontalGap)
+	}
+
+	c := newCanvas(2*maxY+1, columnStart(maxX)+cellWidth)
+
+	// draw labels centered in their cells
+	for _, city := range cities {
+		position := layout[city]
+		label := labels[city]
+		c.write(2*position.Y, columnStart(position.X)+(cellWidth-utf8.RuneCountInString(label))/2, label)
+	}
+
+	// draw roads, each pair of connected cities only once
+	drawn := make(map[[2]simulation.City]struct{})
+	for _, city := range sortedCities(result) {
+		from, ok := layout[city]
+		if !ok {
+			continue
+		}
+
+		for _, road := range roadsOf(result[city]) {
+			if _, ok := result[road.city]; !ok {
+				continue
+			}
+
+			to, ok := layout[road.city]
+			if !ok || !road.leadsTo(from, to) {
+				continue
+			}
+
+			key := [2]simulation.City{city, road.city}
+			if key[0] > key[1] {
+				key[0], key[1] = key[1], key[0]
+			}
+			if _, ok := drawn[key]; ok {
+				continue
+			}
+
+			start, end := from, to
+			if road.dx < 0 || road.dy < 0 {
+				start, end = to, from
+			}
+
+			// the last segment of the road before its destination
+			var arrowRow, arrowCol int
+			var arrow rune
+
+			if road.dy == 0 {
+				first, last := columnStart(start.X)+cellWidth/2+1, columnStart(end.X)+cellWidth/2-1
+				for col := first; col <= last; col++ {
+					c.line(2*start.Y, col, roadHorizontal)
+				}
+
+				// the arrow is placed next to the destination label
+				arrowRow, arrowCol, arrow = 2*start.Y, last, arrowEast
+				for arrowCol > first && c[arrowRow][arrowCol] != roadHorizontal && c[arrowRow][arrowCol] != roadCrossing {
+					arrowCol--
+				}
+				if road.dx < 0 {
+					arrowCol, arrow = first, arrowWest
+					for arrowCol < last && c[arrowRow][arrowCol] != roadHorizontal && c[arrowRow][arrowCol] != roadCrossing {
+						arrowCol++
+					}
+				}
+			} else if road.dx == 0 {
+				first, last := 2*start.Y+1, 2*end.Y-1
+				for row := first; row <= last; row++ {
+					c.line(row, columnStart(start.X)+cellWidth/2, roadVertical)
+				}
+
+				arrowRow, arrowCol, arrow = last, columnStart(start.X)+cellWidth/2, arrowSouth
+				if road.dy < 0 {
+					arrowRow, arrow = first, arrowNorth
+				}
+			} else {
+				// diagonal roads are drawn in the rows between the cities
+				top, bottom := from, to
+				if road.dy < 0 {
+					top, bottom = to, from
+				}
+
+				segment := roadDiagonal
+				if bottom.X < top.X {
+					segment = roadAntiDiag
+				}
+
+				// columns are interpolated from the left end, so crossing diagonals meet in one cell
+				topCol, bottomCol := columnStart(top.X)+cellWidth/2, columnStart(bottom.X)+cellWidth/2
+				rows := 2 * (bottom.Y - top.Y)
+				column := func(row int) int {
+					k := row - 2*top.Y
+					if bottomCol < topCol {
+						return bottomCol + (topCol-bottomCol)*(rows-k)/rows
+					}
+					return topCol + (bottomCol-topCol)*k/rows
+				}
+				for k := 1; k < rows; k++ {
+					c.line(2*top.Y+k, column(2*top.Y+k), segment)
+				}
+
+				arrowRow, arrow = 2*bottom.Y-1, map[[2]int]rune{
+					{1, 1}: arrowSouthEast, {-1, 1}: arrowSouthWest, {1, -1}: arrowNorthEast, {-1, -1}: arrowNorthWest,
+				}[[2]int{road.dx, road.dy}]
+				if road.dy < 0 {
+					arrowRow = 2*top.Y + 1
+				}
+				arrowCol = column(arrowRow)
+			}
+
+			if !leadsBack(result, road.city, city) {
+				c.arrow(arrowRow, arrowCol, arrow)
+			}
+
+			drawn[key] = struct{}{}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(c.String())
+
+	if len(result) < len(initial) {
+		sb.WriteString("\n#City# - destroyed city\n")
+	}
+
+	return sb.String()
+}
+
+// canvas is a rectangular area of characters used to draw a map.
+type canvas [][]rune
+
+func newCanvas(height, width int) canvas {
+	c := make(canvas, height)
+	for i := range c {
+		c[i] = []rune(strings.Repeat(" ", width))
+	}
+	return c
+}
+
+// write puts a text on the canvas starting from a provided position.
+func (c canvas) write(row, col int, text string) {
+	for _, r := range text {
+		c[row][col] = r
+		col++
+	}
+}
+
+// line draws a road segment at a provided position.
+// Labels are never overwritten and crossing roads are joined.
+func (c canvas) line(row, col int, r rune) {
+	switch c[row][col] {
+	case ' ':
+		c[row][col] = r
+	case roadHorizontal, roadVertical:
+		if r == roadHorizontal || r == roadVertical {
+			if c[row][col] != r {
+				c[row][col] = roadCrossing
+			}
+		}
+	case roadDiagonal, roadAntiDiag:
+		if c[row][col] != r && (r == roadDiagonal || r == roadAntiDiag) {
+			c[row][col] = roadDiagCross
+		}
+	}
+}
+
+// arrow marks a direction of a one-way road at a provided position of the road.
+func (c canvas) arrow(row, col int, r rune) {
+	switch c[row][col] {
+	case roadHorizontal, roadVertical, roadCrossing, roadDiagonal, roadAntiDiag, roadDiagCross:
+		c[row][col] = r
+	}
+}
+
+func (c canvas) String() string {
+	var sb strings.Builder
+	for _, row := range c {
+		sb.WriteString(strings.TrimRight(string(row), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// road represents a road leading out of a city in a direction given by a unit vector on the grid.
+// Named exits have no direction on the grid (zero vector).
+type road struct {
+	city   simulation.City
+	dx, dy int
+}
+
+// vectors map compass directions to unit vectors on the grid.
+var vectors = map[simulation.Direction][2]int{
+	simulation.North:     {0, -1},
+	simulation.South:     {0, 1},
+	simulation.East:      {1, 0},
+	simulation.West:      {-1, 0},
+	simulation.NorthEast: {1, -1},
+	simulation.NorthWest: {-1, -1},
+	simulation.SouthEast: {1, 1},
+	simulation.SouthWest: {-1, 1},
+}
+
+// roadsOf returns roads leading out of a city.
+func roadsOf(neighbors simulation.Neighbors) []road {
+	var roads []road
+	directions := append(append([]simulation.Direction(nil), simulation.Directions...), exits(neighbors)...)
+	for _, direction := range directions {
+		if city := neighbors.Road(direction); city != "" {
+			vector := vectors[direction]
+			roads = append(roads, road{city, vector[0], vector[1]})
+		}
+	}
+	return roads
+}
+
+// exits returns named exits of a city in the alphabetical order.
+func exits(neighbors simulation.Neighbors) []simulation.Direction {
+	result := make([]simulation.Direction, 0, len(neighbors.Exits))
+	for direction := range neighbors.Exits {
+		result = append(result, direction)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// leadsTo reports whether the destination lies straight in the direction of the road.
+func (r road) leadsTo(from, to simulation.Coordinates) bool {
+	dx, dy := to.X-from.X, to.Y-from.Y
+
+	steps := dx
+	if steps < 0 {
+		steps = -steps
+	}
+	if dy > steps {
+		steps = dy
+	} else if -dy > steps {
+		steps = -dy
+	}
+
+	return steps > 0 && dx == r.dx*steps && dy == r.dy*steps
+}
+
+// leadsBack reports whether there is a road leading from a city back to the other city.
+func leadsBack(worldMap simulation.WorldMap, from, to simulation.City) bool {
+	for _, road := range roadsOf(worldMap[from]) {
+		if road.city == to {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedCities(worldMap simulation.WorldMap) []simulation.City {
+	cities := make([]simulation.City, 0, len(worldMap))
+	for city := range worldMap {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i] < cities[j] })
+	return cities
+}
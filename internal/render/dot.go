@@ -0,0 +1,250 @@
+package render
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+)
+
+//go:embed grid.dot.tmpl
+var dotGraphTemplate string
+
+//go:embed hex.dot.tmpl
+var hexGraphTemplate string
+
+const (
+	// hexSpacing is a horizontal distance in inches between neighboring cities in a row of a hex grid.
+	hexSpacing = 1.8
+
+	// hexRowSpacing is a vertical distance in inches between rows of a hex grid.
+	hexRowSpacing = 1.5
+)
+
+// DotGraph generates a dot format graph of the initial world map with cities placed on a grid (see NewLayout).
+// Cities missing from the result map are marked red. Hex grids (see IsHex) are drawn
+// as hexagons pinned to their positions by the neato layout.
+// Dot language is used by Graphviz (https://graphviz.org).
+func DotGraph(initial, result simulation.WorldMap) (string, error) {
+	if len(initial) == 0 {
+		return "", fmt.Errorf("map cannot be empty")
+	}
+
+	if IsHex(initial) {
+		return hexGraph(initial, result)
+	}
+
+	layout := NewLayout(initial)
+
+	height, width := 0, 0
+	for _, position := range layout {
+		if position.Y+1 > height {
+			height = position.Y + 1
+		}
+		if position.X+1 > width {
+			width = position.X + 1
+		}
+	}
+
+	grid := make([][]simulation.City, height)
+	for i := range grid {
+		grid[i] = make([]simulation.City, width)
+	}
+	for city, position := range layout {
+		grid[position.Y][position.X] = city
+	}
+
+	var sb strings.Builder
+
+	// generate vertical grid structure
+	column := make([]string, height)
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			column[j] = nodeID(j, i)
+		}
+
+		sb.WriteString(strings.Join(column, " -- ") + "\n")
+	}
+
+	verticalEdges := sb.String()
+	sb.Reset()
+
+	// generate horizontal grid structure
+	row := make([]string, width)
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			row[j] = nodeID(i, j)
+		}
+
+		sb.WriteString(fmt.Sprintf("rank=same {%s}\n", strings.Join(row, " -- ")))
+	}
+
+	horizontalEdges := sb.String()
+	sb.Reset()
+
+	// hide nodes without cities
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			if grid[i][j] == "" {
+				sb.WriteString(fmt.Sprintf("%s [style=invis]\n", nodeID(i, j)))
+			}
+		}
+	}
+
+	hiddenNodes := sb.String()
+	sb.Reset()
+
+	// draw roads between cities, each pair of connected cities only once
+	// one-way roads are drawn with an arrow
+	connectedCities := make(map[simulation.City]struct{})
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			neighbors, ok := initial[grid[i][j]]
+			if !ok {
+				continue
+			}
+
+			for _, road := range roadsOf(neighbors) {
+				position, ok := layout[road.city]
+				if !ok {
+					continue
+				}
+				oneWay := !leadsBack(initial, road.city, grid[i][j])
+				if _, ok := connectedCities[road.city]; ok && !oneWay {
+					continue
+				}
+
+				// roads wrapping around the grid are drawn with a dotted line
+				style := "solid"
+				if !road.leadsTo(simulation.Coordinates{X: j, Y: i}, position) {
+					style = "dotted"
+				}
+				if oneWay {
+					style += ", dir=forward"
+				}
+
+				sb.WriteString(fmt.Sprintf("%s -- %s [style=%s]\n", nodeID(i, j), nodeID(position.Y, position.X), style))
+			}
+
+			connectedCities[grid[i][j]] = struct{}{}
+		}
+	}
+
+	roads := sb.String()
+	sb.Reset()
+
+	// label nodes with city names and mark destroyed cities
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			if grid[i][j] == "" {
+				continue
+			}
+
+			if _, ok := result[grid[i][j]]; ok {
+				sb.WriteString(fmt.Sprintf("%s [label=\"%s\"]\n", nodeID(i, j), grid[i][j]))
+			} else {
+				sb.WriteString(fmt.Sprintf("%s [label=\"%s\", fillcolor=\"red\"]\n", nodeID(i, j), grid[i][j]))
+			}
+		}
+	}
+
+	labels := sb.String()
+
+	// insert generated nodes and edges into the template
+
+	tmpl, err := template.New("").Parse(dotGraphTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var graph bytes.Buffer
+	err = tmpl.Execute(&graph, map[string]string{
+		"hiddenNodes":     hiddenNodes,
+		"verticalEdges":   verticalEdges,
+		"horizontalEdges": horizontalEdges,
+		"roads":           roads,
+		"labels":          labels,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("error generating graph from template: %s", err)
+	}
+
+	return graph.String(), nil
+}
+
+// nodeID returns an identifier of a dot graph node representing a grid cell.
+func nodeID(row, column int) string {
+	return fmt.Sprintf("N%d_%d", row, column)
+}
+
+// hexGraph generates a dot format graph of a hex grid with cities pinned to their positions.
+func hexGraph(initial, result simulation.WorldMap) (string, error) {
+	var sb strings.Builder
+
+	// label nodes with city names and mark destroyed cities
+	for _, city := range sortedCities(initial) {
+		position := initial[city].Coordinates
+
+		color := ""
+		if _, ok := result[city]; !ok {
+			color = ", fillcolor=\"red\""
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"%s [label=\"%s\", pos=\"%.2f,%.2f!\"%s]\n",
+			nodeID(position.Y, position.X), city, float64(position.X)*hexSpacing/2, float64(-position.Y)*hexRowSpacing, color,
+		))
+	}
+
+	nodes := sb.String()
+	sb.Reset()
+
+	// draw roads between cities, each pair of connected cities only once
+	// one-way roads are drawn with an arrow
+	for _, city := range sortedCities(initial) {
+		from := initial[city].Coordinates
+
+		for _, road := range roadsOf(initial[city]) {
+			neighbors, ok := initial[road.city]
+			if !ok {
+				continue
+			}
+
+			oneWay := !leadsBack(initial, road.city, city)
+			if road.city < city && !oneWay {
+				continue
+			}
+
+			style := "solid"
+			if oneWay {
+				style += ", dir=forward"
+			}
+
+			to := neighbors.Coordinates
+			sb.WriteString(fmt.Sprintf("%s -- %s [style=%s]\n", nodeID(from.Y, from.X), nodeID(to.Y, to.X), style))
+		}
+	}
+
+	roads := sb.String()
+
+	tmpl, err := template.New("").Parse(hexGraphTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var graph bytes.Buffer
+	err = tmpl.Execute(&graph, map[string]string{
+		"nodes": nodes,
+		"roads": roads,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("error generating graph from template: %s", err)
+	}
+
+	return graph.String(), nil
+}
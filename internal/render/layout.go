@@ -0,0 +1,243 @@
+package render
+
+import (
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/util"
+)
+
+// Layout assigns grid positions to cities.
+type Layout map[simulation.City]simulation.Coordinates
+
+// maxSparseness is the largest ratio of the grid cells to the cities of a layout using
+// stored coordinates. Sparser coordinates would make the rendered grid too large,
+// so the layout is inferred from the roads instead.
+const maxSparseness = 64
+
+// NewLayout returns a layout using coordinates stored in the world map, shifted so that
+// the first row and the first column are occupied. The layout is inferred from the roads
+// if coordinates of any city are missing or the cities are too sparse (see maxSparseness).
+func NewLayout(worldMap simulation.WorldMap) Layout {
+	first := true
+	var minX, minY, maxX, maxY int
+	for _, neighbors := range worldMap {
+		c := neighbors.Coordinates
+		if c == nil {
+			return InferLayout(worldMap)
+		}
+
+		if first || c.X < minX {
+			minX = c.X
+		}
+		if first || c.Y < minY {
+			minY = c.Y
+		}
+		if first || c.X > maxX {
+			maxX = c.X
+		}
+		if first || c.Y > maxY {
+			maxY = c.Y
+		}
+		first = false
+	}
+
+	if width, height := maxX-minX+1, maxY-minY+1; width*height > maxSparseness*len(worldMap) {
+		return InferLayout(worldMap)
+	}
+
+	layout := make(Layout, len(worldMap))
+	for city, neighbors := range worldMap {
+		layout[city] = simulation.Coordinates{X: neighbors.Coordinates.X - minX, Y: neighbors.Coordinates.Y - minY}
+	}
+
+	return layout
+}
+
+// IsHex reports whether the world map is a hex grid. Hex grids store doubled columns
+// (odd rows are shifted by half a cell), so every city has coordinates with an even sum
+// and no roads lead north or south.
+func IsHex(worldMap simulation.WorldMap) bool {
+	roads := 0
+	for _, neighbors := range worldMap {
+		if neighbors.Coordinates == nil || (neighbors.Coordinates.X+neighbors.Coordinates.Y)%2 != 0 {
+			return false
+		}
+		if neighbors.North != "" || neighbors.South != "" {
+			return false
+		}
+		roads += len(roadsOf(neighbors))
+	}
+
+	return roads > 0
+}
+
+// InferLayout places cities on a grid using directions of the roads between them.
+// Cities connected by north/south roads share a column and cities connected by
+// east/west roads share a row. Columns and rows are ordered to respect directions
+// of the roads, including the diagonal ones. Named exits only join parts of the map.
+// Disconnected parts of the map are placed next to each other.
+// Cities which cannot be placed consistently are moved to separate columns.
+func InferLayout(worldMap simulation.WorldMap) Layout {
+	cities := sortedCities(worldMap)
+
+	index := make(map[simulation.City]int, len(cities))
+	for i, city := range cities {
+		index[city] = i
+	}
+
+	components := util.NewUnionFind(len(cities))
+	columns := util.NewUnionFind(len(cities))
+	rows := util.NewUnionFind(len(cities))
+
+	// order constraints between groups: before -> after
+	var columnOrder, rowOrder [][2]int
+
+	for i, city := range cities {
+		for _, road := range roadsOf(worldMap[city]) {
+			j, ok := index[road.city]
+			if !ok || i == j {
+				continue
+			}
+
+			components.Union(i, j)
+
+			switch {
+			case road.dx == 0 && road.dy != 0:
+				columns.Union(i, j)
+			case road.dy == 0 && road.dx != 0:
+				rows.Union(i, j)
+			}
+
+			if road.dx != 0 {
+				columnOrder = append(columnOrder, ordered(i, j, road.dx > 0))
+			}
+			if road.dy != 0 {
+				rowOrder = append(rowOrder, ordered(i, j, road.dy > 0))
+			}
+		}
+	}
+
+	xs := layers(columns, columnOrder)
+	ys := layers(rows, rowOrder)
+
+	layout := make(Layout, len(cities))
+	offset := 0
+
+	// lay out components in order of their first city
+	members := make(map[int][]int)
+	var roots []int
+	for i := range cities {
+		root := components.Find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	for _, root := range roots {
+		// normalize positions so that every component starts at column 0
+		minX := -1
+		for _, i := range members[root] {
+			if minX == -1 || xs[i] < minX {
+				minX = xs[i]
+			}
+		}
+		minY := -1
+		for _, i := range members[root] {
+			if minY == -1 || ys[i] < minY {
+				minY = ys[i]
+			}
+		}
+
+		width := 0
+		for _, i := range members[root] {
+			if x := xs[i] - minX + 1; x > width {
+				width = x
+			}
+		}
+
+		occupied := make(map[simulation.Coordinates]struct{})
+		for _, i := range members[root] {
+			position := simulation.Coordinates{X: xs[i] - minX, Y: ys[i] - minY}
+			if _, ok := occupied[position]; ok {
+				position.X = width
+				width++
+			}
+			occupied[position] = struct{}{}
+
+			position.X += offset
+			layout[cities[i]] = position
+		}
+
+		offset += width
+	}
+
+	return layout
+}
+
+// ordered returns a pair of elements with the origin of the road first if the road leads forward.
+func ordered(from, to int, forward bool) [2]int {
+	if forward {
+		return [2]int{from, to}
+	}
+	return [2]int{to, from}
+}
+
+// layers assigns a layer to every element using the longest path in a graph of groups.
+// Groups which are part of a cycle are placed after all the other groups.
+func layers(groups *util.UnionFind, order [][2]int) []int {
+	n := groups.Len()
+
+	successors := make(map[int][]int)
+	inDegree := make(map[int]int)
+	for _, edge := range order {
+		before, after := groups.Find(edge[0]), groups.Find(edge[1])
+		if before == after {
+			continue
+		}
+		successors[before] = append(successors[before], after)
+		inDegree[after]++
+	}
+
+	layer := make(map[int]int)
+	var queue []int
+	for i := 0; i < n; i++ {
+		if groups.Find(i) == i && inDegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	maxLayer := 0
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+
+		for _, next := range successors[group] {
+			if layer[group]+1 > layer[next] {
+				layer[next] = layer[group] + 1
+			}
+			if layer[next] > maxLayer {
+				maxLayer = layer[next]
+			}
+
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// place groups from cycles at the end
+	for i := 0; i < n; i++ {
+		if groups.Find(i) == i && inDegree[i] > 0 {
+			maxLayer++
+			layer[i] = maxLayer
+		}
+	}
+
+	result := make([]int, n)
+	for i := range result {
+		result[i] = layer[groups.Find(i)]
+	}
+
+	return result
+}
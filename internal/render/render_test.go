@@ -0,0 +1,231 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	squareMap = simulation.WorldMap{
+		"Anvik": simulation.Neighbors{
+			South: "Fabens",
+			East:  "Hatch",
+		},
+		"Hatch": simulation.Neighbors{
+			South: "Pinson",
+			West:  "Anvik",
+		},
+		"Fabens": simulation.Neighbors{
+			North: "Anvik",
+			East:  "Pinson",
+		},
+		"Pinson": simulation.Neighbors{
+			North: "Hatch",
+			West:  "Fabens",
+		},
+	}
+)
+
+func Test_InferLayout(t *testing.T) {
+	t.Run("cities placed according to road directions", func(t *testing.T) {
+		layout := InferLayout(squareMap)
+
+		expectedLayout := Layout{
+			"Anvik":  simulation.Coordinates{X: 0, Y: 0},
+			"Hatch":  simulation.Coordinates{X: 1, Y: 0},
+			"Fabens": simulation.Coordinates{X: 0, Y: 1},
+			"Pinson": simulation.Coordinates{X: 1, Y: 1},
+		}
+
+		assert.Equal(t, expectedLayout, layout)
+	})
+
+	t.Run("disconnected cities placed next to each other", func(t *testing.T) {
+		layout := InferLayout(simulation.WorldMap{
+			"Anvik": simulation.Neighbors{},
+			"Hatch": simulation.Neighbors{},
+		})
+
+		expectedLayout := Layout{
+			"Anvik": simulation.Coordinates{X: 0, Y: 0},
+			"Hatch": simulation.Coordinates{X: 1, Y: 0},
+		}
+
+		assert.Equal(t, expectedLayout, layout)
+	})
+}
+
+func Test_NewLayout(t *testing.T) {
+	withCoordinates := func(coordinates ...simulation.Coordinates) simulation.WorldMap {
+		worldMap := make(simulation.WorldMap, len(squareMap))
+		for i, city := range []simulation.City{"Anvik", "Hatch", "Fabens", "Pinson"} {
+			neighbors := squareMap[city]
+			neighbors.Coordinates = &coordinates[i]
+			worldMap[city] = neighbors
+		}
+		return worldMap
+	}
+
+	t.Run("stored coordinates used", func(t *testing.T) {
+		layout := NewLayout(withCoordinates(
+			simulation.Coordinates{X: 0, Y: 0}, simulation.Coordinates{X: 2, Y: 0},
+			simulation.Coordinates{X: 0, Y: 2}, simulation.Coordinates{X: 2, Y: 2},
+		))
+
+		assert.Equal(t, simulation.Coordinates{X: 2, Y: 2}, layout["Pinson"])
+	})
+
+	t.Run("coordinates shifted to the first row and column", func(t *testing.T) {
+		expectedLayout := Layout{
+			"Anvik":  simulation.Coordinates{X: 0, Y: 0},
+			"Hatch":  simulation.Coordinates{X: 1, Y: 0},
+			"Fabens": simulation.Coordinates{X: 0, Y: 1},
+			"Pinson": simulation.Coordinates{X: 1, Y: 1},
+		}
+
+		negative := withCoordinates(
+			simulation.Coordinates{X: -1, Y: 0}, simulation.Coordinates{X: 0, Y: 0},
+			simulation.Coordinates{X: -1, Y: 1}, simulation.Coordinates{X: 0, Y: 1},
+		)
+		distant := withCoordinates(
+			simulation.Coordinates{X: 3000, Y: 3000}, simulation.Coordinates{X: 3001, Y: 3000},
+			simulation.Coordinates{X: 3000, Y: 3001}, simulation.Coordinates{X: 3001, Y: 3001},
+		)
+
+		for _, worldMap := range []simulation.WorldMap{negative, distant} {
+			assert.Equal(t, expectedLayout, NewLayout(worldMap))
+
+			graph, err := DotGraph(worldMap, worldMap)
+			require.NoError(t, err)
+			assert.Less(t, len(graph), 10000)
+			assert.Less(t, len(ASCII(worldMap, worldMap)), 1000)
+		}
+	})
+
+	t.Run("sparse coordinates replaced by inferred layout", func(t *testing.T) {
+		worldMap := withCoordinates(
+			simulation.Coordinates{X: 0, Y: 0}, simulation.Coordinates{X: 5000, Y: 0},
+			simulation.Coordinates{X: 0, Y: 5000}, simulation.Coordinates{X: 5000, Y: 5000},
+		)
+
+		assert.Equal(t, InferLayout(worldMap), NewLayout(worldMap))
+	})
+}
+
+func Test_ASCII(t *testing.T) {
+	t.Run("roads drawn between cities", func(t *testing.T) {
+		expected := "" +
+			"[Anvik]────[Hatch]\n" +
+			"    │          │\n" +
+			"[Fabens]───[Pinson]\n"
+
+		assert.Equal(t, expected, ASCII(squareMap, squareMap))
+	})
+
+	t.Run("destroyed city marked", func(t *testing.T) {
+		result := simulation.WorldMap{
+			"Anvik": simulation.Neighbors{
+				South: "Fabens",
+			},
+			"Fabens": simulation.Neighbors{
+				North: "Anvik",
+				East:  "Pinson",
+			},
+			"Pinson": simulation.Neighbors{
+				West: "Fabens",
+			},
+		}
+
+		expected := "" +
+			"[Anvik]    #Hatch#\n" +
+			"    │\n" +
+			"[Fabens]───[Pinson]\n" +
+			"\n" +
+			"#City# - destroyed city\n"
+
+		assert.Equal(t, expected, ASCII(squareMap, result))
+	})
+}
+
+func Test_DiagonalRoads(t *testing.T) {
+	diagonalMap := simulation.WorldMap{
+		"Anvik":  simulation.Neighbors{SouthEast: "Pinson"},
+		"Hatch":  simulation.Neighbors{SouthWest: "Fabens"},
+		"Fabens": simulation.Neighbors{NorthEast: "Hatch", Exits: map[simulation.Direction]simulation.City{"portal": "Pinson"}},
+		"Pinson": simulation.Neighbors{NorthWest: "Anvik", Exits: map[simulation.Direction]simulation.City{"portal": "Fabens"}},
+	}
+
+	t.Run("layout inferred from diagonal roads", func(t *testing.T) {
+		expectedLayout := Layout{
+			"Anvik":  simulation.Coordinates{X: 0, Y: 0},
+			"Hatch":  simulation.Coordinates{X: 1, Y: 0},
+			"Fabens": simulation.Coordinates{X: 0, Y: 1},
+			"Pinson": simulation.Coordinates{X: 1, Y: 1},
+		}
+
+		assert.Equal(t, expectedLayout, InferLayout(diagonalMap))
+	})
+
+	t.Run("ascii crossing diagonals joined", func(t *testing.T) {
+		expected := "" +
+			"[Anvik]    [Hatch]\n" +
+			"         ╳\n" +
+			"[Fabens]   [Pinson]\n"
+
+		assert.Equal(t, expected, ASCII(diagonalMap, diagonalMap))
+	})
+}
+
+func Test_HexGrid(t *testing.T) {
+	hexMap := simulation.WorldMap{
+		"Anvik":  simulation.Neighbors{East: "Hatch", SouthEast: "Fabens", Coordinates: &simulation.Coordinates{X: 0, Y: 0}},
+		"Hatch":  simulation.Neighbors{West: "Anvik", SouthWest: "Fabens", Coordinates: &simulation.Coordinates{X: 2, Y: 0}},
+		"Fabens": simulation.Neighbors{NorthWest: "Anvik", NorthEast: "Hatch", Coordinates: &simulation.Coordinates{X: 1, Y: 1}},
+	}
+
+	t.Run("hex grid detected", func(t *testing.T) {
+		assert.True(t, IsHex(hexMap))
+		assert.False(t, IsHex(squareMap))
+	})
+
+	t.Run("dot cities pinned to hex positions", func(t *testing.T) {
+		graph, err := DotGraph(hexMap, simulation.WorldMap{"Anvik": hexMap["Anvik"], "Hatch": hexMap["Hatch"]})
+		require.NoError(t, err)
+
+		assert.Contains(t, graph, "layout=neato")
+		assert.Contains(t, graph, "N0_2 [label=\"Hatch\", pos=\"1.80,0.00!\"]\n")
+		assert.Contains(t, graph, "N1_1 [label=\"Fabens\", pos=\"0.90,-1.50!\", fillcolor=\"red\"]\n")
+		assert.Contains(t, graph, "N0_0 -- N1_1 [style=solid]\n")
+		assert.NotContains(t, graph, "N1_1 -- N0_0")
+	})
+}
+
+func Test_OneWayRoads(t *testing.T) {
+	oneWayMap := simulation.WorldMap{
+		"Anvik":  simulation.Neighbors{East: "Hatch", South: "Fabens"},
+		"Hatch":  simulation.Neighbors{West: "Anvik"},
+		"Fabens": simulation.Neighbors{East: "Pinson"},
+		"Pinson": simulation.Neighbors{North: "Hatch"},
+	}
+
+	t.Run("ascii arrows point to destinations", func(t *testing.T) {
+		expected := "" +
+			"[Anvik]────[Hatch]\n" +
+			"    ▼          ▲\n" +
+			"[Fabens]──►[Pinson]\n"
+
+		assert.Equal(t, expected, ASCII(oneWayMap, oneWayMap))
+	})
+
+	t.Run("dot roads directed", func(t *testing.T) {
+		graph, err := DotGraph(oneWayMap, oneWayMap)
+		require.NoError(t, err)
+
+		assert.Contains(t, graph, "N0_0 -- N0_1 [style=solid]\n")
+		assert.Contains(t, graph, "N0_0 -- N1_0 [style=solid, dir=forward]\n")
+		assert.Contains(t, graph, "N1_1 -- N0_1 [style=solid, dir=forward]\n")
+	})
+}
@@ -0,0 +1,189 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/world"
+)
+
+// Scenario describes a complete simulation run, so that an experiment can be shared and repeated.
+// Scenarios are stored as JSON files, field names are matched case-insensitively.
+type Scenario struct {
+	// Map is a path of the world map file, relative to the scenario file.
+	Map string `json:"map,omitempty"`
+
+	// InlineMap lists lines of the world map in the map file format in place of Map.
+	InlineMap []string `json:"inlineMap,omitempty"`
+
+	// Seed of the random number generator, a time based seed is used if missing.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// Aliens is a number of the initial aliens, a sum of the faction sizes if factions are provided.
+	Aliens int `json:"aliens"`
+
+	AlienNames  []string               `json:"alienNames,omitempty"`
+	AlienNaming simulation.AlienNaming `json:"alienNaming,omitempty"`
+	SafeNames   bool                   `json:"safeNames,omitempty"`
+	Factions    []simulation.Faction   `json:"factions,omitempty"`
+
+	Placement      simulation.Placement     `json:"placement,omitempty"`
+	LandingZones   int                      `json:"landingZones,omitempty"`
+	FixedPlacement []simulation.PlacedAlien `json:"fixedPlacement,omitempty"`
+
+	CollisionRule simulation.CollisionRule `json:"collisionRule"`
+
+	Squads Squads `json:"squads"`
+
+	Waves        []simulation.Wave `json:"waves,omitempty"`
+	Reproduction int               `json:"reproduction,omitempty"`
+	Fuel         int               `json:"fuel,omitempty"`
+	Reroute      bool              `json:"reroute,omitempty"`
+
+	Stop Stop `json:"stop"`
+}
+
+// Squads configure human resistance squads.
+type Squads struct {
+	Count       int                      `json:"count"`
+	Strategy    simulation.SquadStrategy `json:"strategy,omitempty"`
+	DeathChance float64                  `json:"deathChance"`
+}
+
+// Stop conditions of the simulation (see simulation.Options).
+type Stop struct {
+	Iterations int `json:"iterations"`
+	Aliens     int `json:"aliens,omitempty"`
+	Cities     int `json:"cities,omitempty"`
+}
+
+// Default returns a scenario with the default values of the run command.
+func Default() *Scenario {
+	return &Scenario{
+		Aliens:        50,
+		CollisionRule: simulation.DefaultCollisionRule,
+		Squads:        Squads{DeathChance: 0.5},
+		Stop:          Stop{Iterations: 10000},
+	}
+}
+
+// Load reads a scenario from a provided JSON file. Missing values are taken from Default,
+// unknown fields are rejected to catch misspelled keys.
+// The map path is resolved relative to the directory of the scenario file.
+func Load(path string) (*Scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	s := Default()
+	if err := decoder.Decode(s); err != nil {
+		return nil, fmt.Errorf("error parsing scenario: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("error parsing scenario: unexpected data after the scenario")
+	}
+
+	if s.Map != "" && !filepath.IsAbs(s.Map) {
+		s.Map = filepath.Join(filepath.Dir(path), s.Map)
+	}
+
+	return s, nil
+}
+
+// Save writes the scenario to a provided JSON file.
+// The map path is stored relative to the directory of the scenario file if possible.
+func Save(path string, s *Scenario) error {
+	saved := *s
+	if saved.Map != "" {
+		if absMap, err := filepath.Abs(saved.Map); err == nil {
+			if absDir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+				if rel, err := filepath.Rel(absDir, absMap); err == nil {
+					saved.Map = rel
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding scenario: %w", err)
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// WorldMap loads the world map of the scenario.
+func (s *Scenario) WorldMap() (simulation.WorldMap, error) {
+	switch {
+	case s.Map != "" && len(s.InlineMap) > 0:
+		return nil, fmt.Errorf("scenario cannot contain both a map path and an inline map")
+	case s.Map != "":
+		return world.Load(s.Map)
+	case len(s.InlineMap) > 0:
+		return world.Parse(strings.NewReader(strings.Join(s.InlineMap, "\n")))
+	}
+	return nil, fmt.Errorf("scenario does not contain a map")
+}
+
+// AliensCount returns a number of the initial aliens, a sum of the faction sizes if factions are provided.
+func (s *Scenario) AliensCount() int {
+	if len(s.Factions) == 0 {
+		return s.Aliens
+	}
+
+	count := 0
+	for _, faction := range s.Factions {
+		count += faction.Size
+	}
+	return count
+}
+
+// Options returns options of the simulation described by the scenario.
+func (s *Scenario) Options() simulation.Options {
+	collisionRule := s.CollisionRule
+
+	return simulation.Options{
+		AlienNames:        s.AlienNames,
+		AlienNaming:       s.AlienNaming,
+		SafeNames:         s.SafeNames,
+		Factions:          s.Factions,
+		CollisionRule:     &collisionRule,
+		Squads:            s.Squads.Count,
+		SquadStrategy:     s.Squads.Strategy,
+		SquadDeathChance:  s.Squads.DeathChance,
+		Waves:             s.Waves,
+		ReproductionTurns: s.Reproduction,
+		Fuel:              s.Fuel,
+		Reroute:           s.Reroute,
+		Placement:         s.Placement,
+		LandingZones:      s.LandingZones,
+		FixedPlacement:    s.FixedPlacement,
+		StopAliens:        s.Stop.Aliens,
+		StopCities:        s.Stop.Cities,
+		Seed:              s.Seed,
+	}
+}
+
+// NewSimulation returns a simulation described by the scenario.
+func (s *Scenario) NewSimulation() (*simulation.Simulation, simulation.WorldMap, error) {
+	worldMap, err := s.WorldMap()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading world map: %w", err)
+	}
+
+	sim, err := simulation.NewSimulation(s.Stop.Iterations, s.AliensCount(), worldMap, s.Options())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error initializing simulation: %w", err)
+	}
+
+	return sim, worldMap, nil
+}
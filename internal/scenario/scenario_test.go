@@ -0,0 +1,113 @@
+package scenario
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Load(t *testing.T) {
+	t.Run("missing values taken from defaults", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filepath := path.Join(tempDir, "scenario.json")
+
+		err := os.WriteFile(filepath, []byte(`{"map": "world.map", "seed": 42, "collisionRule": {"capacity": 3}}`), 0644)
+		require.NoError(t, err)
+
+		sc, err := Load(filepath)
+		require.NoError(t, err)
+
+		assert.Equal(t, path.Join(tempDir, "world.map"), sc.Map)
+		assert.Equal(t, int64(42), *sc.Seed)
+		assert.Equal(t, Default().Aliens, sc.Aliens)
+		assert.Equal(t, Default().Stop, sc.Stop)
+		assert.Equal(t, simulation.CollisionRule{MinFighters: 2, DestructionChance: 1, Capacity: 3}, sc.CollisionRule)
+	})
+
+	t.Run("invalid scenario rejected", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filepath := path.Join(tempDir, "scenario.json")
+
+		err := os.WriteFile(filepath, []byte(`{"aliens": "many"}`), 0644)
+		require.NoError(t, err)
+
+		_, err = Load(filepath)
+		assert.Error(t, err)
+	})
+
+	t.Run("misspelled key rejected", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filepath := path.Join(tempDir, "scenario.json")
+
+		for _, content := range []string{
+			`{"map": "world.map", "stop": {"iteration": 100}}`,
+			`{"map": "world.map", "alien": 3}`,
+		} {
+			err := os.WriteFile(filepath, []byte(content), 0644)
+			require.NoError(t, err)
+
+			_, err = Load(filepath)
+			assert.Error(t, err, content)
+		}
+	})
+}
+
+func Test_Save_Load(t *testing.T) {
+	tempDir := t.TempDir()
+	filepath := path.Join(tempDir, "scenario.json")
+
+	seed := int64(7)
+	sc := Default()
+	sc.Map = path.Join(tempDir, "world.map")
+	sc.Seed = &seed
+	sc.Factions = []simulation.Faction{{Name: "red", Size: 2}, {Name: "blue", Size: 3}}
+	sc.Waves = []simulation.Wave{{Iteration: 5, Aliens: 2, Cities: []simulation.City{"Pinson"}, Faction: "red"}}
+	sc.Stop = Stop{Iterations: 100, Aliens: 1}
+
+	err := Save(filepath, sc)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"map": "world.map"`)
+
+	loaded, err := Load(filepath)
+	require.NoError(t, err)
+
+	assert.Equal(t, sc, loaded)
+	assert.Equal(t, 5, loaded.AliensCount())
+}
+
+func Test_NewSimulation(t *testing.T) {
+	t.Run("inline map used", func(t *testing.T) {
+		sc := Default()
+		sc.Aliens = 2
+		sc.InlineMap = []string{"Talihina south=Pinson", "Pinson north=Talihina"}
+
+		_, worldMap, err := sc.NewSimulation()
+		require.NoError(t, err)
+
+		assert.Equal(t, simulation.WorldMap{
+			"Talihina": simulation.Neighbors{South: "Pinson"},
+			"Pinson":   simulation.Neighbors{North: "Talihina"},
+		}, worldMap)
+	})
+
+	t.Run("map path and inline map rejected", func(t *testing.T) {
+		sc := Default()
+		sc.Map = "world.map"
+		sc.InlineMap = []string{"Talihina"}
+
+		_, _, err := sc.NewSimulation()
+		assert.Error(t, err)
+	})
+
+	t.Run("missing map rejected", func(t *testing.T) {
+		_, _, err := Default().NewSimulation()
+		assert.Error(t, err)
+	})
+}
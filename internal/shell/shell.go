@@ -0,0 +1,315 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/world"
+)
+
+// errQuit is returned by the quit command to stop reading commands.
+var errQuit = errors.New("quit")
+
+// Shell drives a simulation with commands typed by the user, one command per line.
+type Shell struct {
+	sim *simulation.Simulation
+	out io.Writer
+
+	// Prompt printed before reading every command.
+	Prompt string
+}
+
+// command of the shell along with its usage and description.
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(sh *Shell, args []string) error
+}
+
+// commands lists all commands of the shell in the order of the help.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"step", "step [N]", "execute N iterations (1 by default)", (*Shell).step},
+		{"back", "back [N]", "rewind N iterations (1 by default)", (*Shell).back},
+		{"show", "show city Foo", "print roads, aliens and squads of a city", (*Shell).show},
+		{"aliens", "aliens", "list aliens with their positions", (*Shell).aliens},
+		{"destroy", "destroy Foo", "destroy a city along with its aliens and squads", (*Shell).destroy},
+		{"place", "place alien X at Foo [faction F]", "land a new alien in a city", (*Shell).place},
+		{"save", "save out.map", "save the current world map to a file", (*Shell).save},
+		{"stats", "stats", "print statistics of the simulation", (*Shell).stats},
+		{"help", "help", "list commands", (*Shell).help},
+		{"quit", "quit", "exit the shell", (*Shell).quit},
+	}
+}
+
+// New returns a shell driving a provided simulation and printing to a provided writer.
+// Iterations of the simulation are recorded, so that they can be rewound.
+func New(sim *simulation.Simulation, out io.Writer) *Shell {
+	sim.Record()
+	return &Shell{sim: sim, out: out, Prompt: "> "}
+}
+
+// Run executes commands read from a reader until its end or the quit command.
+// Errors of the commands are printed and do not stop the shell.
+func (sh *Shell) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(sh.out, sh.Prompt)
+		if !scanner.Scan() {
+			break
+		}
+
+		err := sh.Execute(scanner.Text())
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(sh.out, "Error: %s\n", err)
+		}
+	}
+	fmt.Fprintln(sh.out)
+
+	return scanner.Err()
+}
+
+// Execute executes a single command line, empty lines are ignored.
+func (sh *Shell) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	name := fields[0]
+	if name == "exit" {
+		name = "quit"
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(sh, fields[1:])
+		}
+	}
+
+	return fmt.Errorf("unknown command: %s (type help to list commands)", fields[0])
+}
+
+func (sh *Shell) step(args []string) error {
+	count, err := countArg(args)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		if sh.sim.ShouldStop() {
+			fmt.Fprintf(sh.out, "Simulation finished at iteration %d\n", sh.sim.Iteration())
+			return nil
+		}
+		sh.sim.Step()
+	}
+
+	fmt.Fprintf(sh.out, "Iteration %d\n", sh.sim.Iteration())
+	return nil
+}
+
+func (sh *Shell) back(args []string) error {
+	count, err := countArg(args)
+	if err != nil {
+		return err
+	}
+
+	if err := sh.sim.Rewind(sh.sim.Iteration() - count); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(sh.out, "Iteration %d\n", sh.sim.Iteration())
+	return nil
+}
+
+func (sh *Shell) show(args []string) error {
+	if len(args) != 2 || args[0] != "city" {
+		return fmt.Errorf("usage: show city Foo")
+	}
+	city := simulation.City(args[1])
+
+	neighbors, ok := sh.sim.WorldMap()[city]
+	if !ok {
+		if _, ok := sh.sim.InitialMap()[city]; ok {
+			fmt.Fprintf(sh.out, "%s has been destroyed\n", city)
+			return nil
+		}
+		return fmt.Errorf("unknown city: %s", city)
+	}
+
+	fmt.Fprint(sh.out, simulation.WorldMap{city: neighbors}.String())
+
+	var aliens []string
+	for alien, position := range sh.sim.AlienPositions() {
+		if position == city {
+			aliens = append(aliens, string(alien))
+		}
+	}
+
+	var squads []string
+	for squad, position := range sh.sim.SquadPositions() {
+		if position == city {
+			squads = append(squads, string(squad))
+		}
+	}
+
+	fmt.Fprintf(sh.out, "Aliens: %s\n", joinSorted(aliens))
+	fmt.Fprintf(sh.out, "Squads: %s\n", joinSorted(squads))
+	return nil
+}
+
+func (sh *Shell) aliens(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: aliens")
+	}
+
+	var lines []string
+	for alien, city := range sh.sim.AlienPositions() {
+		lines = append(lines, fmt.Sprintf("%s in %s%s", alien, city, sh.faction(alien)))
+	}
+	for alien, city := range sh.sim.AliensInTransit() {
+		lines = append(lines, fmt.Sprintf("%s travelling to %s%s", alien, city, sh.faction(alien)))
+	}
+	sort.Strings(lines)
+
+	if len(lines) == 0 {
+		fmt.Fprintln(sh.out, "No aliens left")
+	}
+	for _, line := range lines {
+		fmt.Fprintln(sh.out, line)
+	}
+	return nil
+}
+
+func (sh *Shell) destroy(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: destroy Foo")
+	}
+
+	if err := sh.sim.DestroyCity(simulation.City(args[0])); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(sh.out, "%s has been destroyed\n", args[0])
+	return nil
+}
+
+func (sh *Shell) place(args []string) error {
+	// alien names can contain whitespaces, the name ends at the last "at"
+	at := -1
+	for i, arg := range args {
+		if arg == "at" {
+			at = i
+		}
+	}
+
+	rest := args[at+1:]
+	if len(args) < 4 || args[0] != "alien" || at < 2 || (len(rest) != 1 && (len(rest) != 3 || rest[1] != "faction")) {
+		return fmt.Errorf("usage: place alien X at Foo [faction F]")
+	}
+
+	alien := simulation.Alien(strings.Join(args[1:at], " "))
+	city := simulation.City(rest[0])
+	faction := ""
+	if len(rest) == 3 {
+		faction = rest[2]
+	}
+
+	if err := sh.sim.PlaceAlien(alien, city, faction); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(sh.out, "%s has landed in %s\n", alien, city)
+	return nil
+}
+
+func (sh *Shell) save(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: save out.map")
+	}
+
+	if err := world.Save(args[0], sh.sim.WorldMap()); err != nil {
+		return fmt.Errorf("error saving world map: %w", err)
+	}
+
+	fmt.Fprintf(sh.out, "World map saved to %s\n", args[0])
+	return nil
+}
+
+func (sh *Shell) stats(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: stats")
+	}
+
+	inTransit := len(sh.sim.AliensInTransit())
+
+	fmt.Fprintf(sh.out, "Iteration: %d (can be rewound to %d)\n", sh.sim.Iteration(), sh.sim.Recorded())
+	fmt.Fprintf(sh.out, "Cities: %d of %d\n", len(sh.sim.WorldMap()), len(sh.sim.InitialMap()))
+	fmt.Fprintf(sh.out, "Aliens: %d (%d in transit)\n", len(sh.sim.AlienPositions())+inTransit, inTransit)
+
+	for _, faction := range sh.sim.FactionSurvivors() {
+		fmt.Fprintf(sh.out, "Faction %s: %d of %d aliens\n", faction.Name, faction.Survivors, faction.Size)
+	}
+
+	if survivors, total := sh.sim.SquadSurvivors(); total > 0 {
+		fmt.Fprintf(sh.out, "Squads: %d of %d\n", survivors, total)
+	}
+
+	if sh.sim.ShouldStop() {
+		fmt.Fprintln(sh.out, "Simulation finished")
+	}
+	return nil
+}
+
+func (sh *Shell) help(args []string) error {
+	for _, c := range commands {
+		fmt.Fprintf(sh.out, "  %-34s %s\n", c.usage, c.description)
+	}
+	return nil
+}
+
+func (sh *Shell) quit(args []string) error {
+	return errQuit
+}
+
+// faction returns a description of the faction of an alien, an empty string if the alien has no faction.
+func (sh *Shell) faction(alien simulation.Alien) string {
+	if faction := sh.sim.AlienFaction(alien); faction != "" {
+		return fmt.Sprintf(" (%s)", faction)
+	}
+	return ""
+}
+
+// countArg parses an optional positive count argument, 1 by default.
+func countArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+
+	count, err := strconv.Atoi(args[0])
+	if len(args) > 1 || err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid count: %s", strings.Join(args, " "))
+	}
+	return count, nil
+}
+
+// joinSorted returns names in the alphabetical order joined with commas, "none" if there are no names.
+func joinSorted(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
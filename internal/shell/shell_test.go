@@ -0,0 +1,83 @@
+package shell
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/world"
+)
+
+func Test_Shell(t *testing.T) {
+	newShell := func(t *testing.T) (*Shell, *simulation.Simulation, *bytes.Buffer) {
+		worldMap := simulation.WorldMap{
+			"Foo": simulation.Neighbors{East: "Bar"},
+			"Bar": simulation.Neighbors{West: "Foo", East: "Baz"},
+			"Baz": simulation.Neighbors{West: "Bar"},
+		}
+
+		seed := int64(1)
+		sim, err := simulation.NewSimulation(100, 0, worldMap, simulation.Options{
+			Factions: []simulation.Faction{{Name: "red", Size: 0}},
+			Seed:     &seed,
+		})
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		sh := New(sim, &out)
+		sh.Prompt = ""
+		return sh, sim, &out
+	}
+
+	t.Run("aliens placed and listed", func(t *testing.T) {
+		sh, sim, out := newShell(t)
+
+		err := sh.Run(strings.NewReader("place alien Zorg the Great at Foo faction red\nplace alien Blip at Baz\naliens\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, simulation.AlienPositions{"Zorg the Great": "Foo", "Blip": "Baz"}, sim.AlienPositions())
+		assert.Contains(t, out.String(), "Blip in Baz\nZorg the Great in Foo (red)\n")
+	})
+
+	t.Run("iterations stepped and rewound", func(t *testing.T) {
+		sh, sim, out := newShell(t)
+
+		require.NoError(t, sh.Run(strings.NewReader("place alien Zorg at Foo\nstep 3\nback 2\n")))
+		assert.Equal(t, 1, sim.Iteration())
+		assert.Contains(t, out.String(), "Iteration 3\nIteration 1\n")
+
+		out.Reset()
+		require.NoError(t, sh.Run(strings.NewReader("back 5\n")))
+		assert.Contains(t, out.String(), "Error: cannot rewind")
+		assert.Equal(t, 1, sim.Iteration())
+	})
+
+	t.Run("destroyed city shown and saved", func(t *testing.T) {
+		sh, sim, out := newShell(t)
+		path := filepath.Join(t.TempDir(), "out.map")
+
+		require.NoError(t, sh.Run(strings.NewReader("destroy Bar\nshow city Bar\nshow city Foo\nsave "+path+"\nstats\n")))
+
+		saved, err := world.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, sim.WorldMap(), saved)
+		assert.Len(t, saved, 2)
+
+		assert.Contains(t, out.String(), "Bar has been destroyed\nBar has been destroyed\nFoo\nAliens: none\nSquads: none\n")
+		assert.Contains(t, out.String(), "Cities: 2 of 3\n")
+	})
+
+	t.Run("invalid commands reported", func(t *testing.T) {
+		sh, _, out := newShell(t)
+
+		require.NoError(t, sh.Run(strings.NewReader("fly\nstep -1\nplace alien at Foo\nshow Foo\nquit\nstats\n")))
+
+		assert.Equal(t, 4, strings.Count(out.String(), "Error: "))
+		assert.NotContains(t, out.String(), "Iteration:")
+	})
+}
@@ -0,0 +1,226 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/maruqu/alien-invasion/internal/util"
+)
+
+// checkpointVersion is increased whenever the checkpoint format changes incompatibly.
+const checkpointVersion = 1
+
+// checkpoint is a serializable state of the simulation.
+type checkpoint struct {
+	Version int `json:"version"`
+
+	IterationCounter int `json:"iterationCounter"`
+	IterationLimit   int `json:"iterationLimit"`
+
+	WorldMap   WorldMap `json:"worldMap"`
+	InitialMap WorldMap `json:"initialMap"`
+
+	AlienPositions map[Alien]City   `json:"alienPositions"`
+	Factions       []Faction        `json:"factions,omitempty"`
+	AlienFactions  map[Alien]string `json:"alienFactions,omitempty"`
+	CollisionRule  *CollisionRule   `json:"collisionRule,omitempty"`
+
+	SquadPositions map[Squad]City    `json:"squadPositions,omitempty"`
+	SquadTransit   map[Squad]journey `json:"squadTransit,omitempty"`
+	SquadsCount    int               `json:"squadsCount,omitempty"`
+	SquadStrategy  SquadStrategy     `json:"squadStrategy,omitempty"`
+	SquadDeath     float64           `json:"squadDeath,omitempty"`
+
+	AlienNaming  AlienNaming `json:"alienNaming"`
+	AlienNames   []string    `json:"alienNames,omitempty"`
+	SafeNames    bool        `json:"safeNames,omitempty"`
+	NamesCounter int         `json:"namesCounter"`
+	UsedNames    []Alien     `json:"usedNames"`
+
+	Waves        []Wave          `json:"waves,omitempty"`
+	Reproduction int             `json:"reproduction,omitempty"`
+	LoneTurns    map[Alien]int   `json:"loneTurns,omitempty"`
+	Lineages     map[Alien]Alien `json:"lineages,omitempty"`
+
+	Fuel       map[Alien]int `json:"fuel,omitempty"`
+	FuelBudget int           `json:"fuelBudget,omitempty"`
+	Stalled    []Alien       `json:"stalled,omitempty"`
+
+	Transit map[Alien]journey `json:"transit,omitempty"`
+	Reroute bool              `json:"reroute,omitempty"`
+
+	StopAliens int `json:"stopAliens,omitempty"`
+	StopCities int `json:"stopCities,omitempty"`
+
+	// RandomState is the state of the random number generator of the simulation.
+	RandomState uint64 `json:"randomState"`
+}
+
+// Checkpoint returns the state of the simulation serialized as JSON.
+// The simulation can be continued from the checkpoint with Resume.
+func (s *Simulation) Checkpoint() ([]byte, error) {
+	c := checkpoint{
+		Version:          checkpointVersion,
+		IterationCounter: s.iterationCounter,
+		IterationLimit:   s.iterationLimit,
+		WorldMap:         s.worldMap,
+		InitialMap:       s.initialMap,
+		AlienPositions:   s.alienPositions,
+		Factions:         s.factions,
+		AlienFactions:    s.alienFactions,
+		CollisionRule:    s.collisionRule,
+		SquadPositions:   s.squadPositions,
+		SquadTransit:     s.squadTransit,
+		SquadsCount:      s.squadsCount,
+		SquadStrategy:    s.squadStrategy,
+		SquadDeath:       s.squadDeath,
+		Waves:            s.waves,
+		Reproduction:     s.reproduction,
+		LoneTurns:        s.loneTurns,
+		Lineages:         s.lineages,
+		Fuel:             s.fuel,
+		FuelBudget:       s.fuelBudget,
+		Stalled:          sortedSet(s.stalled),
+		Transit:          s.transit,
+		Reroute:          s.reroute,
+		StopAliens:       s.stopAliens,
+		StopCities:       s.stopCities,
+		RandomState:      s.randomState(),
+	}
+
+	if s.namer != nil {
+		c.AlienNaming = s.namer.naming
+		c.AlienNames = s.namer.source
+		c.SafeNames = s.namer.safe
+		c.NamesCounter = s.namer.counter
+		c.UsedNames = sortedSet(s.namer.used)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding checkpoint: %w", err)
+	}
+
+	return data, nil
+}
+
+// Resume returns a simulation restored from a checkpoint created by Checkpoint.
+func Resume(data []byte) (*Simulation, error) {
+	var c checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error decoding checkpoint: %w", err)
+	}
+
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version: %d", c.Version)
+	}
+
+	source := util.NewSource(0)
+	rnd := rand.New(source)
+
+	namer, err := newAlienNamer(0, Options{AlienNames: c.AlienNames, AlienNaming: c.AlienNaming, SafeNames: c.SafeNames}, rnd)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring alien names: %w", err)
+	}
+	namer.counter = c.NamesCounter
+	for _, alien := range c.UsedNames {
+		namer.reserve(alien)
+	}
+
+	source.SetState(c.RandomState)
+
+	s := &Simulation{
+		iterationCounter: c.IterationCounter,
+		iterationLimit:   c.IterationLimit,
+		worldMap:         nonNilMap(c.WorldMap),
+		initialMap:       nonNilMap(c.InitialMap),
+		alienPositions:   c.AlienPositions,
+		factions:         c.Factions,
+		alienFactions:    c.AlienFactions,
+		collisionRule:    c.CollisionRule,
+		squadPositions:   c.SquadPositions,
+		squadTransit:     c.SquadTransit,
+		squadsCount:      c.SquadsCount,
+		squadStrategy:    c.SquadStrategy,
+		squadDeath:       c.SquadDeath,
+		namer:            namer,
+		waves:            c.Waves,
+		reproduction:     c.Reproduction,
+		loneTurns:        c.LoneTurns,
+		lineages:         c.Lineages,
+		fuel:             c.Fuel,
+		fuelBudget:       c.FuelBudget,
+		stalled:          make(map[Alien]struct{}, len(c.Stalled)),
+		transit:          c.Transit,
+		reroute:          c.Reroute,
+		stopAliens:       c.StopAliens,
+		stopCities:       c.StopCities,
+		rnd:              rnd,
+		source:           source,
+		resumed:          true,
+	}
+
+	if s.alienPositions == nil {
+		s.alienPositions = make(AlienPositions)
+	}
+	if s.loneTurns == nil {
+		s.loneTurns = make(map[Alien]int)
+	}
+	for _, alien := range c.Stalled {
+		s.stalled[alien] = struct{}{}
+	}
+
+	return s, nil
+}
+
+// SetCheckpoint makes Run pass a checkpoint (see Checkpoint) to a provided function
+// after every provided number of iterations. Zero disables checkpoints.
+func (s *Simulation) SetCheckpoint(every int, save func(data []byte) error) {
+	s.checkpointEvery = every
+	s.saveCheckpoint = save
+}
+
+// checkpoint saves a checkpoint if it is due in the current iteration.
+func (s *Simulation) checkpoint() error {
+	if s.checkpointEvery <= 0 || s.iterationCounter%s.checkpointEvery != 0 {
+		return nil
+	}
+	return s.saveState()
+}
+
+// saveState saves a checkpoint of the current iteration if checkpoints are configured.
+func (s *Simulation) saveState() error {
+	if s.checkpointEvery <= 0 || s.saveCheckpoint == nil {
+		return nil
+	}
+
+	data, err := s.Checkpoint()
+	if err != nil {
+		return err
+	}
+
+	if err := s.saveCheckpoint(data); err != nil {
+		return fmt.Errorf("error saving checkpoint: %w", err)
+	}
+	return nil
+}
+
+// sortedSet returns elements of a set in the increasing order.
+func sortedSet(set map[Alien]struct{}) []Alien {
+	result := make([]Alien, 0, len(set))
+	for alien := range set {
+		result = append(result, alien)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// nonNilMap returns the provided world map or an empty world map if it is nil.
+func nonNilMap(worldMap WorldMap) WorldMap {
+	if worldMap == nil {
+		return make(WorldMap)
+	}
+	return worldMap
+}
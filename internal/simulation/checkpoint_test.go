@@ -0,0 +1,92 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Checkpoint(t *testing.T) {
+	gridMap := WorldMap{
+		"Anvik":  Neighbors{East: "Hatch", South: "Fabens", Lengths: map[Direction]int{East: 2}},
+		"Hatch":  Neighbors{West: "Anvik", South: "Pinson", Lengths: map[Direction]int{West: 2}},
+		"Fabens": Neighbors{North: "Anvik", East: "Pinson", HP: 3},
+		"Pinson": Neighbors{North: "Hatch", West: "Fabens", Defense: 1},
+	}
+
+	newSimulation := func(t *testing.T) *Simulation {
+		seed := int64(42)
+		s, err := NewSimulation(100, 4, gridMap, Options{
+			AlienNaming:       AlienNamingGenerate,
+			Factions:          []Faction{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+			Squads:            1,
+			SquadDeathChance:  0.5,
+			Waves:             []Wave{{Iteration: 3, Aliens: 2}},
+			ReproductionTurns: 2,
+			Fuel:              20,
+			CollisionRule:     &CollisionRule{MinFighters: 2, DestructionChance: 0.2, RandomSurvivor: true},
+			Seed:              &seed,
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("resumed simulation continues like the original one", func(t *testing.T) {
+		original := newSimulation(t)
+		for i := 0; i < 10; i++ {
+			original.Step()
+		}
+
+		interrupted := newSimulation(t)
+		for i := 0; i < 4; i++ {
+			interrupted.Step()
+		}
+
+		data, err := interrupted.Checkpoint()
+		require.NoError(t, err)
+
+		resumed, err := Resume(data)
+		require.NoError(t, err)
+		for i := 0; i < 6; i++ {
+			resumed.Step()
+		}
+
+		expected, err := original.Checkpoint()
+		require.NoError(t, err)
+		actual, err := resumed.Checkpoint()
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, resumed.alienPositions)
+		assert.JSONEq(t, string(expected), string(actual))
+		assert.Equal(t, gridMap, resumed.InitialMap())
+	})
+
+	t.Run("checkpoints saved periodically by run", func(t *testing.T) {
+		s := newSimulation(t)
+
+		var iterations []int
+		s.SetCheckpoint(3, func(data []byte) error {
+			resumed, err := Resume(data)
+			require.NoError(t, err)
+			iterations = append(iterations, resumed.iterationCounter)
+			return nil
+		})
+
+		_, err := s.Run(context.Background())
+		require.NoError(t, err)
+
+		for i, iteration := range iterations {
+			assert.Equal(t, 3*(i+1), iteration)
+		}
+	})
+
+	t.Run("unsupported checkpoint rejected", func(t *testing.T) {
+		_, err := Resume([]byte(`{"version": 0}`))
+		assert.Error(t, err)
+
+		_, err = Resume([]byte(`not a checkpoint`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,48 @@
+package simulation
+
+import "fmt"
+
+// CollisionRule configures what happens when aliens meet in a city.
+type CollisionRule struct {
+	// MinFighters is a minimal number of aliens in a city required to start a fight.
+	// Aliens of at least two different factions are always required.
+	MinFighters int `json:"minFighters"`
+
+	// DestructionChance is a probability that a fight destroys the city,
+	// the city is left standing and only the fighting aliens are killed otherwise.
+	DestructionChance float64 `json:"destructionChance"`
+
+	// RandomSurvivor keeps one random fighting alien alive if the city is left standing.
+	RandomSurvivor bool `json:"randomSurvivor,omitempty"`
+
+	// Capacity is a maximal number of aliens a city can hold. A city holding more aliens
+	// is destroyed along with all the aliens even if they do not fight. Zero means no limit.
+	Capacity int `json:"capacity,omitempty"`
+
+	// RoadFights makes enemy aliens travelling in opposite directions along the same road fight on the road.
+	// The fighting aliens are killed and the road is destroyed.
+	RoadFights bool `json:"roadFights,omitempty"`
+
+	// RoadFightsDestroyCities makes road fights destroy both cities at the ends of the road.
+	RoadFightsDestroyCities bool `json:"roadFightsDestroyCities,omitempty"`
+}
+
+// DefaultCollisionRule destroys a city along with all the aliens when two enemy aliens meet in it.
+var DefaultCollisionRule = CollisionRule{
+	MinFighters:       2,
+	DestructionChance: 1,
+}
+
+func (r CollisionRule) validate() error {
+	if r.MinFighters < 2 {
+		return fmt.Errorf("at least two fighters are required")
+	}
+	if r.DestructionChance < 0 || r.DestructionChance > 1 {
+		return fmt.Errorf("destruction chance must be between 0 and 1")
+	}
+	if r.Capacity < 0 {
+		return fmt.Errorf("city capacity cannot be negative")
+	}
+
+	return nil
+}
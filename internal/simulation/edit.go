@@ -0,0 +1,98 @@
+package simulation
+
+import "fmt"
+
+// WorldMap returns the current world map.
+func (s *Simulation) WorldMap() WorldMap {
+	return s.worldMap
+}
+
+// AlienPositions returns positions of the aliens in the cities, aliens in transit are omitted.
+func (s *Simulation) AlienPositions() AlienPositions {
+	result := make(AlienPositions, len(s.alienPositions))
+	for alien, city := range s.alienPositions {
+		result[alien] = city
+	}
+	return result
+}
+
+// AliensInTransit returns destinations of the aliens travelling along the roads.
+func (s *Simulation) AliensInTransit() AlienPositions {
+	result := make(AlienPositions, len(s.transit))
+	for alien, j := range s.transit {
+		result[alien] = j.To
+	}
+	return result
+}
+
+// SquadPositions returns positions of the human squads.
+func (s *Simulation) SquadPositions() map[Squad]City {
+	result := make(map[Squad]City, len(s.squadPositions))
+	for squad, city := range s.squadPositions {
+		result[squad] = city
+	}
+	return result
+}
+
+// AlienFaction returns the faction of an alien, an empty string if the alien has no faction.
+func (s *Simulation) AlienFaction(alien Alien) string {
+	return s.alienFactions[alien]
+}
+
+// DestroyCity destroys a city along with the aliens and squads in it.
+// The recorded iterations before the change cannot be rewound anymore.
+func (s *Simulation) DestroyCity(city City) error {
+	if _, ok := s.worldMap[city]; !ok {
+		return fmt.Errorf("unknown city: %s", city)
+	}
+
+	s.killAliens(s.aliensIn(city))
+	s.destroyCity(city)
+
+	s.edited()
+	return nil
+}
+
+// PlaceAlien lands a new alien of a provided faction (none if empty) in a city.
+// The recorded iterations before the change cannot be rewound anymore.
+func (s *Simulation) PlaceAlien(alien Alien, city City, faction string) error {
+	if _, ok := s.worldMap[city]; !ok {
+		return fmt.Errorf("unknown city: %s", city)
+	}
+
+	_, placed := s.alienPositions[alien]
+	_, travelling := s.transit[alien]
+	if alien == "" || placed || travelling {
+		return fmt.Errorf("alien name already used: %q", alien)
+	}
+
+	if faction != "" && !s.hasFaction(faction) {
+		return fmt.Errorf("unknown faction: %s", faction)
+	}
+
+	if s.namer != nil {
+		s.namer.reserve(alien)
+	}
+	s.land(alien, city, faction)
+
+	s.edited()
+	return nil
+}
+
+// hasFaction reports whether there is a faction with a provided name.
+func (s *Simulation) hasFaction(name string) bool {
+	for _, faction := range s.factions {
+		if faction.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// edited restarts the recording of the simulation after the state was changed outside of a step,
+// since the recorded deltas do not cover such changes.
+func (s *Simulation) edited() {
+	if s.history != nil {
+		s.history = &history{start: s.iterationCounter}
+	}
+}
@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Edit(t *testing.T) {
+	newSimulation := func(t *testing.T) *Simulation {
+		worldMap := WorldMap{
+			"Foo": Neighbors{East: "Bar"},
+			"Bar": Neighbors{West: "Foo"},
+			"Baz": Neighbors{},
+		}
+
+		seed := int64(1)
+		s, err := NewSimulation(10, 0, worldMap, Options{
+			Factions: []Faction{{Name: "red", Size: 0}},
+			Fuel:     5,
+			Seed:     &seed,
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("placed aliens join the simulation", func(t *testing.T) {
+		s := newSimulation(t)
+
+		require.NoError(t, s.PlaceAlien("Zorg", "Foo", "red"))
+		require.NoError(t, s.PlaceAlien("Blip the Great", "Baz", ""))
+
+		assert.Equal(t, AlienPositions{"Zorg": "Foo", "Blip the Great": "Baz"}, s.AlienPositions())
+		assert.Equal(t, "red", s.AlienFaction("Zorg"))
+		assert.Equal(t, 5, s.fuel["Zorg"])
+		assert.Equal(t, []FactionSurvivors{{Faction: Faction{Name: "red", Size: 1}, Survivors: 1}}, s.FactionSurvivors())
+		assert.False(t, s.ShouldStop())
+	})
+
+	t.Run("invalid aliens rejected", func(t *testing.T) {
+		s := newSimulation(t)
+		require.NoError(t, s.PlaceAlien("Zorg", "Foo", ""))
+
+		assert.Error(t, s.PlaceAlien("Zorg", "Bar", ""))
+		assert.Error(t, s.PlaceAlien("Blip", "Qux", ""))
+		assert.Error(t, s.PlaceAlien("Blip", "Bar", "blue"))
+		assert.Error(t, s.PlaceAlien("", "Bar", ""))
+	})
+
+	t.Run("destroyed city removed with its aliens and roads", func(t *testing.T) {
+		s := newSimulation(t)
+		require.NoError(t, s.PlaceAlien("Zorg", "Foo", ""))
+		require.NoError(t, s.PlaceAlien("Blip", "Bar", ""))
+
+		require.NoError(t, s.DestroyCity("Foo"))
+
+		assert.Equal(t, WorldMap{"Bar": Neighbors{}, "Baz": Neighbors{}}, s.WorldMap())
+		assert.Equal(t, AlienPositions{"Blip": "Bar"}, s.AlienPositions())
+		assert.Error(t, s.DestroyCity("Foo"))
+	})
+
+	t.Run("edits restart the recording", func(t *testing.T) {
+		s := newSimulation(t)
+		s.Record()
+		require.NoError(t, s.PlaceAlien("Zorg", "Foo", ""))
+		s.Step()
+		s.Step()
+
+		require.NoError(t, s.DestroyCity("Baz"))
+
+		assert.Equal(t, 2, s.Recorded())
+		assert.Error(t, s.StepBack())
+	})
+}
@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"fmt"
+	"log"
+)
+
+func (o Options) validateFuel() error {
+	if o.Fuel < 0 {
+		return fmt.Errorf("fuel cannot be negative")
+	}
+	return nil
+}
+
+// affordable returns the directions of the roads which an alien has enough fuel to travel along (see Neighbors.Cost).
+// An alien which cannot afford any of the roads stalls and stays in place for the rest of the simulation.
+func (s *Simulation) affordable(alien Alien, neighbors Neighbors, directions []Direction) []Direction {
+	var result []Direction
+	for _, direction := range directions {
+		if neighbors.Cost(direction) <= s.fuel[alien] {
+			result = append(result, direction)
+		}
+	}
+
+	if len(result) == 0 && len(directions) > 0 {
+		if _, ok := s.stalled[alien]; !ok {
+			s.recordAlien(alien)
+			s.stalled[alien] = struct{}{}
+			log.Printf("%s has run out of fuel in %s!", alien, s.alienPositions[alien])
+		}
+	}
+
+	return result
+}
+
+// immobile reports whether no alien can move anymore and the state of the simulation cannot change.
+// It is only possible when the aliens have limited fuel.
+func (s *Simulation) immobile() bool {
+	if s.fuel == nil || len(s.transit) > 0 || s.pendingWaves() || s.reproduction > 0 || len(s.squadPositions)+len(s.squadTransit) > 0 {
+		return false
+	}
+
+	for alien, city := range s.alienPositions {
+		neighbors := s.worldMap[city]
+		for _, direction := range neighbors.directions() {
+			if neighbors.Cost(direction) <= s.fuel[alien] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fuel(t *testing.T) {
+	t.Run("alien stalls without fuel", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson"},
+			"Pinson":   Neighbors{North: "Talihina"},
+		}
+
+		s, err := NewSimulation(100, 1, worldMap, Options{Fuel: 2})
+		require.NoError(t, err)
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina"}
+		s.fuel = map[Alien]int{"Alien 1": 2}
+
+		s.Step()
+		s.Step()
+		assert.Equal(t, City("Talihina"), s.alienPositions["Alien 1"])
+		assert.True(t, s.ShouldStop())
+
+		s.Step()
+		assert.Equal(t, City("Talihina"), s.alienPositions["Alien 1"])
+		assert.Contains(t, s.stalled, Alien("Alien 1"))
+	})
+
+	t.Run("alien cannot afford a costly road", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson", Costs: map[Direction]int{South: 3}},
+			"Pinson":   Neighbors{North: "Talihina", Costs: map[Direction]int{North: 3}},
+		}
+
+		s, err := NewSimulation(100, 1, worldMap, Options{Fuel: 2})
+		require.NoError(t, err)
+
+		assert.True(t, s.ShouldStop())
+	})
+
+	t.Run("road cost paid independently of the road length", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson", Lengths: map[Direction]int{South: 3}, Costs: map[Direction]int{South: 2}},
+			"Pinson":   Neighbors{North: "Talihina", Lengths: map[Direction]int{North: 3}},
+		}
+
+		s, err := NewSimulation(100, 1, worldMap, Options{Fuel: 4})
+		require.NoError(t, err)
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina"}
+		s.fuel = map[Alien]int{"Alien 1": 4}
+
+		for i := 0; i < 3; i++ {
+			s.Step()
+		}
+		assert.Equal(t, City("Pinson"), s.alienPositions["Alien 1"])
+		assert.Equal(t, 2, s.fuel["Alien 1"])
+
+		for i := 0; i < 3; i++ {
+			s.Step()
+		}
+		assert.Equal(t, City("Talihina"), s.alienPositions["Alien 1"])
+		assert.Equal(t, 1, s.fuel["Alien 1"])
+	})
+}
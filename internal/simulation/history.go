@@ -0,0 +1,232 @@
+package simulation
+
+import "fmt"
+
+// history records changes of the state made by every iteration, so that the iterations can be undone.
+type history struct {
+	// start is the iteration at which the recording started.
+	start int
+
+	// deltas of the consecutive iterations following start.
+	deltas []delta
+
+	// current is the delta of the iteration in progress, nil outside of a step.
+	current *delta
+}
+
+// delta holds the state overwritten by an iteration.
+type delta struct {
+	randomState uint64
+
+	// undo restores the state changed by the iteration, the entries are applied in the reverse order
+	undo []func()
+}
+
+// Record makes the simulation record changes made by every following iteration,
+// so that it can be rewound to any iteration since the current one (see Rewind).
+func (s *Simulation) Record() {
+	if s.history == nil {
+		s.history = &history{start: s.iterationCounter}
+	}
+}
+
+// Iteration returns a number of the executed iterations.
+func (s *Simulation) Iteration() int {
+	return s.iterationCounter
+}
+
+// Recorded returns the earliest iteration the simulation can be rewound to.
+// The current iteration is returned if the simulation is not recorded.
+func (s *Simulation) Recorded() int {
+	if s.history == nil {
+		return s.iterationCounter
+	}
+	return s.history.start
+}
+
+// Rewind restores the state of the simulation before a provided iteration.
+// Stepping forward again repeats the rewound iterations exactly, as the random number generator is restored as well.
+func (s *Simulation) Rewind(iteration int) error {
+	if iteration < s.Recorded() || iteration > s.iterationCounter {
+		return fmt.Errorf("cannot rewind to iteration %d, recorded iterations: %d-%d", iteration, s.Recorded(), s.iterationCounter)
+	}
+
+	for s.iterationCounter > iteration {
+		last := len(s.history.deltas) - 1
+		s.undo(s.history.deltas[last])
+		s.history.deltas = s.history.deltas[:last]
+		s.iterationCounter -= 1
+	}
+
+	return nil
+}
+
+// StepBack undoes the last iteration.
+func (s *Simulation) StepBack() error {
+	return s.Rewind(s.iterationCounter - 1)
+}
+
+// undo restores the state overwritten by an iteration.
+func (s *Simulation) undo(d delta) {
+	for i := len(d.undo) - 1; i >= 0; i-- {
+		d.undo[i]()
+	}
+	s.source.SetState(d.randomState)
+}
+
+// beginDelta starts recording changes of an iteration if the simulation is recorded.
+func (s *Simulation) beginDelta() {
+	if s.history != nil {
+		s.history.current = &delta{randomState: s.randomState()}
+	}
+}
+
+// endDelta stores the changes of the finished iteration.
+func (s *Simulation) endDelta() {
+	if s.history != nil && s.history.current != nil {
+		s.history.deltas = append(s.history.deltas, *s.history.current)
+		s.history.current = nil
+	}
+}
+
+// recording reports whether the changes of the current iteration are recorded.
+// Nothing is recorded outside of a step or if the simulation is not recorded.
+func (s *Simulation) recording() bool {
+	return s.history != nil && s.history.current != nil
+}
+
+// onUndo adds an entry restoring the state changed by the current iteration.
+func (s *Simulation) onUndo(undo func()) {
+	if s.recording() {
+		s.history.current.undo = append(s.history.current.undo, undo)
+	}
+}
+
+// recordAlien records the state of an alien before it is changed.
+func (s *Simulation) recordAlien(alien Alien) {
+	if !s.recording() {
+		return
+	}
+
+	city, placed := s.alienPositions[alien]
+	faction, hasFaction := s.alienFactions[alien]
+	j, travelling := s.transit[alien]
+	fuel, hasFuel := s.fuel[alien]
+	_, stalled := s.stalled[alien]
+	turns, lone := s.loneTurns[alien]
+	first, spawned := s.lineages[alien]
+
+	s.onUndo(func() {
+		if placed {
+			s.alienPositions[alien] = city
+		} else {
+			delete(s.alienPositions, alien)
+		}
+		if hasFaction {
+			s.alienFactions[alien] = faction
+		} else {
+			delete(s.alienFactions, alien)
+		}
+		if travelling {
+			s.transit[alien] = j
+		} else {
+			delete(s.transit, alien)
+		}
+		if hasFuel {
+			s.fuel[alien] = fuel
+		} else {
+			delete(s.fuel, alien)
+		}
+		if stalled {
+			s.stalled[alien] = struct{}{}
+		} else {
+			delete(s.stalled, alien)
+		}
+		if lone {
+			s.loneTurns[alien] = turns
+		} else {
+			delete(s.loneTurns, alien)
+		}
+		if spawned {
+			s.lineages[alien] = first
+		} else {
+			delete(s.lineages, alien)
+		}
+	})
+}
+
+// recordAlienPositions records the positions of the aliens before they are replaced with a new map.
+func (s *Simulation) recordAlienPositions() {
+	previous := s.alienPositions
+	s.onUndo(func() { s.alienPositions = previous })
+}
+
+// recordLoneTurns records the lone turns of the aliens before they are replaced with a new map.
+func (s *Simulation) recordLoneTurns() {
+	previous := s.loneTurns
+	s.onUndo(func() { s.loneTurns = previous })
+}
+
+// recordCity records a city along with its roads before it is changed or destroyed.
+func (s *Simulation) recordCity(city City) {
+	if !s.recording() {
+		return
+	}
+
+	neighbors, ok := s.worldMap[city]
+	neighbors = neighbors.copy()
+
+	s.onUndo(func() {
+		if ok {
+			s.worldMap[city] = neighbors
+		} else {
+			delete(s.worldMap, city)
+		}
+	})
+}
+
+// recordSquad records the state of a squad before it is changed.
+func (s *Simulation) recordSquad(squad Squad) {
+	if !s.recording() {
+		return
+	}
+
+	city, placed := s.squadPositions[squad]
+	j, travelling := s.squadTransit[squad]
+
+	s.onUndo(func() {
+		if placed {
+			s.squadPositions[squad] = city
+		} else {
+			delete(s.squadPositions, squad)
+		}
+		if travelling {
+			s.squadTransit[squad] = j
+		} else {
+			delete(s.squadTransit, squad)
+		}
+	})
+}
+
+// recordName records a name taken from the namer along with the previous counter of the namer.
+func (s *Simulation) recordName(alien Alien, counter int) {
+	s.onUndo(func() {
+		s.namer.release(alien)
+		s.namer.counter = counter
+	})
+}
+
+// recordFactions records sizes of the factions before they are changed.
+func (s *Simulation) recordFactions() {
+	if !s.recording() {
+		return
+	}
+
+	previous := append([]Faction(nil), s.factions...)
+	s.onUndo(func() { s.factions = previous })
+}
+
+// randomState returns the state of the random number generator of the simulation.
+func (s *Simulation) randomState() uint64 {
+	return s.source.State()
+}
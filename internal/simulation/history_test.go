@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Rewind(t *testing.T) {
+	gridMap := WorldMap{
+		"Anvik":  Neighbors{East: "Hatch", South: "Fabens", Lengths: map[Direction]int{East: 2}},
+		"Hatch":  Neighbors{West: "Anvik", South: "Pinson", Lengths: map[Direction]int{West: 2}},
+		"Fabens": Neighbors{North: "Anvik", East: "Pinson", HP: 3},
+		"Pinson": Neighbors{North: "Hatch", West: "Fabens", Defense: 1},
+	}
+
+	newSimulation := func(t *testing.T) *Simulation {
+		seed := int64(7)
+		s, err := NewSimulation(100, 4, gridMap, Options{
+			AlienNaming:       AlienNamingGenerate,
+			Factions:          []Faction{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+			Squads:            1,
+			SquadDeathChance:  0.5,
+			Waves:             []Wave{{Iteration: 3, Aliens: 2, Faction: "red"}},
+			ReproductionTurns: 2,
+			Fuel:              20,
+			CollisionRule:     &CollisionRule{MinFighters: 2, DestructionChance: 0.2, RandomSurvivor: true},
+			Seed:              &seed,
+		})
+		require.NoError(t, err)
+		return s
+	}
+
+	// states returns checkpoints of the simulation after every step
+	states := func(t *testing.T, s *Simulation, steps int) []string {
+		var result []string
+		for i := 0; i < steps; i++ {
+			s.Step()
+
+			data, err := s.Checkpoint()
+			require.NoError(t, err)
+			result = append(result, string(data))
+		}
+		return result
+	}
+
+	t.Run("rewound simulation repeats the iterations", func(t *testing.T) {
+		s := newSimulation(t)
+		initial, err := s.Checkpoint()
+		require.NoError(t, err)
+
+		s.Record()
+		expected := states(t, s, 10)
+		assert.NotEmpty(t, s.alienPositions)
+
+		require.NoError(t, s.Rewind(4))
+		assert.Equal(t, 4, s.Iteration())
+		actual, err := s.Checkpoint()
+		require.NoError(t, err)
+		assert.JSONEq(t, expected[3], string(actual))
+
+		for i, state := range states(t, s, 6) {
+			assert.JSONEq(t, expected[4+i], state)
+		}
+
+		require.NoError(t, s.StepBack())
+		actual, err = s.Checkpoint()
+		require.NoError(t, err)
+		assert.JSONEq(t, expected[8], string(actual))
+
+		require.NoError(t, s.Rewind(0))
+		actual, err = s.Checkpoint()
+		require.NoError(t, err)
+		assert.JSONEq(t, string(initial), string(actual))
+
+		for i, state := range states(t, s, 10) {
+			assert.JSONEq(t, expected[i], state)
+		}
+	})
+
+	t.Run("rewound simulation restores destroyed cities and roads", func(t *testing.T) {
+		gridMap := WorldMap{
+			"Anvik":    Neighbors{East: "Hatch", South: "Fabens", Lengths: map[Direction]int{East: 2}},
+			"Hatch":    Neighbors{West: "Anvik", East: "Keystone", South: "Pinson", Lengths: map[Direction]int{West: 2}},
+			"Keystone": Neighbors{West: "Hatch", South: "Steprock", Costs: map[Direction]int{South: 2}},
+			"Fabens":   Neighbors{North: "Anvik", East: "Pinson", HP: 2},
+			"Pinson":   Neighbors{North: "Hatch", West: "Fabens", East: "Steprock"},
+			"Steprock": Neighbors{North: "Keystone", West: "Pinson", Costs: map[Direction]int{North: 2}},
+		}
+
+		seed := int64(26)
+		s, err := NewSimulation(100, 6, gridMap, Options{
+			Factions:          []Faction{{Name: "red", Size: 3}, {Name: "blue", Size: 3}},
+			Squads:            2,
+			SquadStrategy:     SquadStrategyHunt,
+			SquadDeathChance:  0.5,
+			ReproductionTurns: 2,
+			Reroute:           true,
+			CollisionRule:     &CollisionRule{MinFighters: 2, DestructionChance: 0.3, RoadFights: true},
+			Seed:              &seed,
+		})
+		require.NoError(t, err)
+		initial, err := s.Checkpoint()
+		require.NoError(t, err)
+
+		s.Record()
+		expected := states(t, s, 10)
+		assert.Less(t, len(s.worldMap), len(gridMap))
+
+		require.NoError(t, s.Rewind(5))
+		actual, err := s.Checkpoint()
+		require.NoError(t, err)
+		assert.JSONEq(t, expected[4], string(actual))
+
+		require.NoError(t, s.Rewind(0))
+		actual, err = s.Checkpoint()
+		require.NoError(t, err)
+		assert.JSONEq(t, string(initial), string(actual))
+		assert.Equal(t, gridMap, s.worldMap)
+
+		for i, state := range states(t, s, 10) {
+			assert.JSONEq(t, expected[i], state)
+		}
+	})
+
+	t.Run("iterations outside of the recording cannot be rewound", func(t *testing.T) {
+		s := newSimulation(t)
+		s.Step()
+		s.Step()
+
+		assert.Error(t, s.StepBack())
+
+		s.Record()
+		s.Step()
+		assert.Equal(t, 2, s.Recorded())
+
+		assert.Error(t, s.Rewind(1))
+		assert.Error(t, s.Rewind(4))
+		assert.NoError(t, s.Rewind(2))
+		assert.Error(t, s.StepBack())
+	})
+}
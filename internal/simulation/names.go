@@ -0,0 +1,188 @@
+package simulation
+
+import (
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/maruqu/alien-invasion/internal/namegen"
+)
+
+//go:embed alien-names.txt
+var alienNames string
+
+// AlienNaming selects how aliens are named.
+type AlienNaming string
+
+const (
+	// AlienNamingList takes names from the source.
+	// If there are more aliens than names, all aliens are numbered like in AlienNamingNumbered.
+	AlienNamingList AlienNaming = "list"
+
+	// AlienNamingNumbered names aliens "Alien 1", "Alien 2" etc.
+	AlienNamingNumbered AlienNaming = "numbered"
+
+	// AlienNamingGenerate generates an unlimited number of new names resembling the source names.
+	AlienNamingGenerate AlienNaming = "generate"
+)
+
+// AlienNamings lists all supported alien naming modes.
+var AlienNamings = []AlienNaming{
+	AlienNamingList,
+	AlienNamingNumbered,
+	AlienNamingGenerate,
+}
+
+// alienNamer creates unique alien names for the initial aliens and the aliens joining the simulation later.
+type alienNamer struct {
+	naming AlienNaming
+
+	// source is a custom list of names provided in the options, nil for the embedded list.
+	source    []string
+	names     []string
+	safe      bool
+	generator *namegen.Generator
+	counter   int
+	used      map[Alien]struct{}
+}
+
+// newAlienNamer returns an alienNamer for a provided count of initial aliens.
+// Generated names are drawn using a provided random number generator.
+func newAlienNamer(count int, options Options, rnd *rand.Rand) (*alienNamer, error) {
+	names := options.AlienNames
+	if names == nil {
+		names = strings.Split(strings.TrimSpace(alienNames), "\n")
+	}
+
+	if options.SafeNames {
+		safeNames := make([]string, len(names))
+		for i, name := range names {
+			safeNames[i] = safeName(name)
+		}
+		names = safeNames
+	}
+
+	n := &alienNamer{
+		naming: options.AlienNaming,
+		source: options.AlienNames,
+		names:  names,
+		safe:   options.SafeNames,
+		used:   make(map[Alien]struct{}),
+	}
+
+	switch n.naming {
+	case "", AlienNamingList:
+		n.naming = AlienNamingList
+		if count > len(names) {
+			n.naming = AlienNamingNumbered
+		}
+	case AlienNamingNumbered:
+	case AlienNamingGenerate:
+		generator, err := namegen.New(names, rnd)
+		if err != nil {
+			return nil, err
+		}
+		n.generator = generator
+	default:
+		return nil, fmt.Errorf("unknown alien naming: %s", options.AlienNaming)
+	}
+
+	if n.naming == AlienNamingList {
+		unique := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			if _, ok := unique[name]; ok {
+				return nil, fmt.Errorf("duplicated alien name: %s", name)
+			}
+			unique[name] = struct{}{}
+		}
+	}
+
+	return n, nil
+}
+
+// next returns a name which was not returned before.
+// When the list of names is exhausted, aliens are numbered.
+func (n *alienNamer) next() Alien {
+	var alien Alien
+
+	switch {
+	case n.generator != nil:
+		name, err := n.generator.Next()
+		if err != nil {
+			// the generator cannot produce a name of an acceptable length, the alien is numbered instead
+			alien = n.numbered()
+			break
+		}
+		alien = Alien(name)
+	case n.naming == AlienNamingList && n.counter < len(n.names):
+		alien = Alien(n.names[n.counter])
+		n.counter += 1
+
+		// names reserved for the fixed placement are skipped
+		if _, ok := n.used[alien]; ok {
+			return n.next()
+		}
+	default:
+		alien = n.numbered()
+	}
+
+	n.used[alien] = struct{}{}
+	return alien
+}
+
+// numbered returns the next unused numbered name.
+func (n *alienNamer) numbered() Alien {
+	for {
+		n.counter += 1
+
+		name := fmt.Sprintf("Alien %d", n.counter)
+		if n.naming == AlienNamingList {
+			name = fmt.Sprintf("Alien %d", n.counter-len(n.names))
+		}
+		if n.safe {
+			name = safeName(name)
+		}
+
+		if _, ok := n.used[Alien(name)]; !ok {
+			return Alien(name)
+		}
+	}
+}
+
+// reserve marks a name provided from outside of the namer as used.
+func (n *alienNamer) reserve(alien Alien) {
+	n.used[alien] = struct{}{}
+	if n.generator != nil {
+		n.generator.Use(string(alien))
+	}
+}
+
+// release marks a name as unused again, e.g. when the iteration which used it is rewound.
+func (n *alienNamer) release(alien Alien) {
+	delete(n.used, alien)
+	if n.generator != nil {
+		n.generator.Release(string(alien))
+	}
+}
+
+// take returns a slice of aliens with new names with a provided count.
+func (n *alienNamer) take(count int) []Alien {
+	result := make([]Alien, count)
+	for i := range result {
+		result[i] = n.next()
+	}
+	return result
+}
+
+// safeName replaces whitespaces and special characters in a name with underscores,
+// so that the name is a single word which can be parsed from the log.
+func safeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+}
@@ -0,0 +1,224 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Placement selects how the initial aliens are placed on the map.
+type Placement string
+
+const (
+	// PlacementRandom places every alien in a uniformly random city.
+	PlacementRandom Placement = "random"
+
+	// PlacementOnePerCity places every alien in a different city, so there are no initial fights.
+	// It requires at least as many cities as aliens.
+	PlacementOnePerCity Placement = "one-per-city"
+
+	// PlacementClustered places aliens in random cities close to randomly picked landing zones.
+	PlacementClustered Placement = "clustered"
+
+	// PlacementDegree places aliens in random cities with a probability proportional to the number of roads.
+	PlacementDegree Placement = "degree"
+
+	// PlacementAvoidCollision places every alien in a random city without its enemies.
+	// Empty cities are kept for the sides which have not landed yet if possible
+	// and aliens are placed randomly if every city already holds an enemy.
+	PlacementAvoidCollision Placement = "avoid-collision"
+)
+
+// Placements lists all supported placements.
+var Placements = []Placement{
+	PlacementRandom,
+	PlacementOnePerCity,
+	PlacementClustered,
+	PlacementDegree,
+	PlacementAvoidCollision,
+}
+
+// clusterRadius is a maximal number of roads between a landing zone and a city of the zone.
+const clusterRadius = 1
+
+// PlacedAlien is an alien landing in a provided city.
+type PlacedAlien struct {
+	Alien Alien `json:"alien"`
+	City  City  `json:"city"`
+}
+
+func (o Options) validatePlacement(aliensCount int, worldMap WorldMap) error {
+	if o.LandingZones < 0 {
+		return fmt.Errorf("landing zones count cannot be negative")
+	}
+
+	switch o.Placement {
+	case "", PlacementRandom, PlacementClustered, PlacementDegree, PlacementAvoidCollision:
+	case PlacementOnePerCity:
+		if aliensCount > len(worldMap) {
+			return fmt.Errorf("too many aliens (%d) to place one per city (%d cities)", aliensCount, len(worldMap))
+		}
+	default:
+		return fmt.Errorf("unknown placement: %s", o.Placement)
+	}
+
+	if len(o.FixedPlacement) == 0 {
+		return nil
+	}
+
+	if len(o.FixedPlacement) != aliensCount {
+		return fmt.Errorf("fixed placement lists %d aliens, %d expected", len(o.FixedPlacement), aliensCount)
+	}
+
+	aliens := make(map[Alien]struct{}, len(o.FixedPlacement))
+	for _, placed := range o.FixedPlacement {
+		if _, ok := aliens[placed.Alien]; ok {
+			return fmt.Errorf("duplicated alien in fixed placement: %s", placed.Alien)
+		}
+		aliens[placed.Alien] = struct{}{}
+
+		if _, ok := worldMap[placed.City]; !ok {
+			return fmt.Errorf("unknown city in fixed placement: %s", placed.City)
+		}
+	}
+
+	return nil
+}
+
+// placeAliens assigns positions on the map for the provided aliens according to the placement.
+func (s *Simulation) placeAliens(aliens []Alien, placement Placement, landingZones int) AlienPositions {
+	cities := make([]City, 0, len(s.worldMap))
+	for city := range s.worldMap {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i] < cities[j] })
+
+	alienPositions := make(AlienPositions, len(aliens))
+
+	switch placement {
+	case PlacementOnePerCity:
+		s.rnd.Shuffle(len(cities), func(i, j int) { cities[i], cities[j] = cities[j], cities[i] })
+		for i, alien := range aliens {
+			alienPositions[alien] = cities[i]
+		}
+	case PlacementClustered:
+		zones := s.landingZones(cities, landingZones)
+		for _, alien := range aliens {
+			zone := zones[s.rnd.Intn(len(zones))]
+			alienPositions[alien] = zone[s.rnd.Intn(len(zone))]
+		}
+	case PlacementDegree:
+		weights := make([]int, len(cities))
+		total := 0
+		for i, city := range cities {
+			weights[i] = len(s.worldMap[city].directions())
+			total += weights[i]
+		}
+
+		for _, alien := range aliens {
+			if total == 0 {
+				alienPositions[alien] = cities[s.rnd.Intn(len(cities))]
+				continue
+			}
+
+			r := s.rnd.Intn(total)
+			for i, weight := range weights {
+				if r < weight {
+					alienPositions[alien] = cities[i]
+					break
+				}
+				r -= weight
+			}
+		}
+	case PlacementAvoidCollision:
+		// sides maps a city to the side of its aliens, unplaced counts aliens of every side left to place
+		sides := make(map[City]string, len(cities))
+		unplaced := make(map[string]int)
+		for _, alien := range aliens {
+			unplaced[s.side(alien)] += 1
+		}
+		placedSides := make(map[string]struct{})
+
+		for _, alien := range aliens {
+			side := s.side(alien)
+			unplaced[side] -= 1
+
+			var own, empty []City
+			for _, city := range cities {
+				switch citySide, ok := sides[city]; {
+				case !ok:
+					empty = append(empty, city)
+				case citySide == side:
+					own = append(own, city)
+				}
+			}
+
+			// empty cities are kept for the sides which have not landed yet
+			waiting := 0
+			for other, count := range unplaced {
+				if _, ok := placedSides[other]; !ok && other != side && count > 0 {
+					waiting += 1
+				}
+			}
+
+			candidates := append(own, empty...)
+			if len(own) > 0 && len(empty) <= waiting {
+				candidates = own
+			}
+			if len(candidates) == 0 {
+				candidates = cities
+			}
+
+			city := candidates[s.rnd.Intn(len(candidates))]
+			if _, ok := sides[city]; !ok {
+				sides[city] = side
+			}
+			placedSides[side] = struct{}{}
+			alienPositions[alien] = city
+		}
+	default:
+		for _, alien := range aliens {
+			alienPositions[alien] = cities[s.rnd.Intn(len(cities))]
+		}
+	}
+
+	return alienPositions
+}
+
+// landingZones picks a provided number of random cities (one if zero) and returns
+// every picked city along with the cities within clusterRadius roads from it.
+func (s *Simulation) landingZones(cities []City, count int) [][]City {
+	if count == 0 {
+		count = 1
+	}
+
+	zones := make([][]City, count)
+	for i := range zones {
+		center := cities[s.rnd.Intn(len(cities))]
+
+		distances := map[City]int{center: 0}
+		queue := []City{center}
+		zones[i] = []City{center}
+
+		for len(queue) > 0 {
+			city := queue[0]
+			queue = queue[1:]
+			if distances[city] == clusterRadius {
+				continue
+			}
+
+			neighbors := s.worldMap[city]
+			for _, direction := range neighbors.directions() {
+				next := neighbors.Road(direction)
+				if _, ok := distances[next]; ok {
+					continue
+				}
+
+				distances[next] = distances[city] + 1
+				queue = append(queue, next)
+				zones[i] = append(zones[i], next)
+			}
+		}
+	}
+
+	return zones
+}
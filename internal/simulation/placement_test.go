@@ -0,0 +1,108 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Placement(t *testing.T) {
+	t.Run("one alien per city", func(t *testing.T) {
+		s, err := NewSimulation(10, 5, starMap, Options{Placement: PlacementOnePerCity})
+		require.NoError(t, err)
+
+		cities := make(map[City]struct{})
+		for _, city := range s.alienPositions {
+			cities[city] = struct{}{}
+		}
+		assert.Len(t, cities, 5)
+	})
+
+	t.Run("too many aliens for one per city rejected", func(t *testing.T) {
+		_, err := NewSimulation(10, 6, starMap, Options{Placement: PlacementOnePerCity})
+		assert.Error(t, err)
+	})
+
+	t.Run("clustered aliens land close to each other", func(t *testing.T) {
+		lineMap := WorldMap{
+			"A": Neighbors{East: "B"},
+			"B": Neighbors{West: "A", East: "C"},
+			"C": Neighbors{West: "B", East: "D"},
+			"D": Neighbors{West: "C", East: "E"},
+			"E": Neighbors{West: "D"},
+		}
+
+		for i := 0; i < 10; i++ {
+			s, err := NewSimulation(10, 20, lineMap, Options{Placement: PlacementClustered})
+			require.NoError(t, err)
+
+			first, last := City("E"), City("A")
+			for _, city := range s.alienPositions {
+				if city < first {
+					first = city
+				}
+				if city > last {
+					last = city
+				}
+			}
+			assert.LessOrEqual(t, int(last[0]-first[0]), 2*clusterRadius)
+		}
+	})
+
+	t.Run("cities without roads skipped by degree placement", func(t *testing.T) {
+		s, err := NewSimulation(10, 20, simpleMap, Options{Placement: PlacementDegree})
+		require.NoError(t, err)
+
+		for _, city := range s.alienPositions {
+			assert.NotEqual(t, City("Clifton"), city)
+		}
+	})
+
+	t.Run("enemies placed in separate cities", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson"},
+			"Pinson":   Neighbors{North: "Talihina"},
+		}
+		factions := []Faction{{Name: "red", Size: 3}, {Name: "blue", Size: 3}}
+
+		s, err := NewSimulation(10, 6, worldMap, Options{Placement: PlacementAvoidCollision, Factions: factions})
+		require.NoError(t, err)
+
+		sides := make(map[City]map[string]struct{})
+		for alien, city := range s.alienPositions {
+			if sides[city] == nil {
+				sides[city] = make(map[string]struct{})
+			}
+			sides[city][s.alienFactions[alien]] = struct{}{}
+		}
+		for _, factions := range sides {
+			assert.Len(t, factions, 1)
+		}
+	})
+
+	t.Run("fixed placement used", func(t *testing.T) {
+		placement := []PlacedAlien{{"Zorg", "Pinson"}, {"Xan", "Fabens"}}
+
+		s, err := NewSimulation(10, 2, simpleMap, Options{FixedPlacement: placement})
+		require.NoError(t, err)
+
+		assert.Equal(t, AlienPositions{"Zorg": "Pinson", "Xan": "Fabens"}, AlienPositions(s.alienPositions))
+	})
+
+	t.Run("invalid fixed placement rejected", func(t *testing.T) {
+		_, err := NewSimulation(10, 1, simpleMap, Options{FixedPlacement: []PlacedAlien{{"Zorg", "Atlantis"}}})
+		assert.Error(t, err)
+
+		_, err = NewSimulation(10, 3, simpleMap, Options{FixedPlacement: []PlacedAlien{{"Zorg", "Pinson"}}})
+		assert.Error(t, err)
+
+		_, err = NewSimulation(10, 2, simpleMap, Options{FixedPlacement: []PlacedAlien{{"Zorg", "Pinson"}, {"Zorg", "Fabens"}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown placement rejected", func(t *testing.T) {
+		_, err := NewSimulation(10, 1, simpleMap, Options{Placement: "unknown"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,166 @@
+package simulation
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Wave is a group of aliens landing on the map at the beginning of an iteration.
+type Wave struct {
+	// Iteration at which the aliens land, starting from 1.
+	Iteration int `json:"iteration"`
+
+	// Aliens is a number of landing aliens.
+	Aliens int `json:"aliens"`
+
+	// Cities the aliens land in (evenly distributed), random cities if empty or all of them are destroyed.
+	Cities []City `json:"cities,omitempty"`
+
+	// Faction of the landing aliens, no faction by default.
+	Faction string `json:"faction,omitempty"`
+}
+
+func (o Options) validateReinforcements() error {
+	if o.ReproductionTurns < 0 {
+		return fmt.Errorf("reproduction turns cannot be negative")
+	}
+
+	factions := make(map[string]struct{}, len(o.Factions))
+	for _, faction := range o.Factions {
+		factions[faction.Name] = struct{}{}
+	}
+
+	for _, wave := range o.Waves {
+		if wave.Iteration < 1 || wave.Aliens < 0 {
+			return fmt.Errorf("invalid wave of %d aliens at iteration %d", wave.Aliens, wave.Iteration)
+		}
+
+		if _, ok := factions[wave.Faction]; wave.Faction != "" && !ok {
+			return fmt.Errorf("unknown faction of wave: %s", wave.Faction)
+		}
+	}
+
+	return nil
+}
+
+// pendingWaves reports whether there are waves which have not landed yet.
+func (s *Simulation) pendingWaves() bool {
+	for _, wave := range s.waves {
+		if wave.Iteration > s.iterationCounter {
+			return true
+		}
+	}
+	return false
+}
+
+// landWaves places aliens of the waves scheduled for the current iteration on the map.
+func (s *Simulation) landWaves() {
+	for _, wave := range s.waves {
+		if wave.Iteration != s.iterationCounter+1 || len(s.worldMap) == 0 {
+			continue
+		}
+
+		var cities []City
+		for _, city := range wave.Cities {
+			if _, ok := s.worldMap[city]; ok {
+				cities = append(cities, city)
+			}
+		}
+
+		if len(cities) == 0 {
+			for city := range s.worldMap {
+				cities = append(cities, city)
+			}
+			sort.Slice(cities, func(i, j int) bool { return cities[i] < cities[j] })
+
+			for i := 0; i < wave.Aliens; i++ {
+				s.addAlien(cities[s.rnd.Intn(len(cities))], wave.Faction)
+			}
+		} else {
+			for i := 0; i < wave.Aliens; i++ {
+				s.addAlien(cities[i%len(cities)], wave.Faction)
+			}
+		}
+
+		log.Printf("%d aliens have landed!", wave.Aliens)
+	}
+}
+
+// reproduce makes aliens which spent enough turns alone in a city spawn another alien.
+func (s *Simulation) reproduce() {
+	if s.reproduction == 0 {
+		return
+	}
+
+	cityAliens := make(map[City][]Alien)
+	for alien, city := range s.alienPositions {
+		cityAliens[city] = append(cityAliens[city], alien)
+	}
+
+	lone := make([]Alien, 0, len(cityAliens))
+	for _, aliens := range cityAliens {
+		if len(aliens) == 1 {
+			lone = append(lone, aliens[0])
+		}
+	}
+	sort.Slice(lone, func(i, j int) bool { return lone[i] < lone[j] })
+
+	loneTurns := make(map[Alien]int, len(lone))
+	for _, alien := range lone {
+		loneTurns[alien] = s.loneTurns[alien] + 1
+
+		if loneTurns[alien] >= s.reproduction {
+			loneTurns[alien] = 0
+
+			city := s.alienPositions[alien]
+			child := s.addAlien(city, s.alienFactions[alien])
+
+			// an alien without a faction shares its side with its offspring
+			if _, ok := s.alienFactions[alien]; !ok {
+				if s.lineages == nil {
+					s.lineages = make(map[Alien]Alien)
+				}
+				s.lineages[child] = s.lineage(alien)
+			}
+			log.Printf("%s has spawned %s in %s!", alien, child, city)
+		}
+	}
+
+	s.recordLoneTurns()
+	s.loneTurns = loneTurns
+}
+
+// addAlien places a new alien of a provided faction in a city.
+func (s *Simulation) addAlien(city City, faction string) Alien {
+	counter := s.namer.counter
+	alien := s.namer.next()
+	s.recordName(alien, counter)
+
+	s.land(alien, city, faction)
+	return alien
+}
+
+// land places an alien of a provided faction in a city.
+func (s *Simulation) land(alien Alien, city City, faction string) {
+	s.recordAlien(alien)
+	s.alienPositions[alien] = city
+
+	if s.fuel != nil {
+		s.fuel[alien] = s.fuelBudget
+	}
+
+	if faction != "" {
+		if s.alienFactions == nil {
+			s.alienFactions = make(map[Alien]string)
+		}
+		s.alienFactions[alien] = faction
+
+		s.recordFactions()
+		for i := range s.factions {
+			if s.factions[i].Name == faction {
+				s.factions[i].Size += 1
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Reinforcements(t *testing.T) {
+	t.Run("wave lands in chosen cities", func(t *testing.T) {
+		s, err := NewSimulation(10, 0, simpleMap, Options{
+			Waves: []Wave{{Iteration: 3, Aliens: 2, Cities: []City{"Clifton"}}},
+		})
+		require.NoError(t, err)
+
+		s.Step()
+		s.Step()
+		assert.Empty(t, s.alienPositions)
+		assert.False(t, s.ShouldStop())
+
+		s.landWaves()
+		assert.Len(t, s.alienPositions, 2)
+		for _, city := range s.alienPositions {
+			assert.Equal(t, City("Clifton"), city)
+		}
+	})
+
+	t.Run("wave faction has to exist", func(t *testing.T) {
+		_, err := NewSimulation(10, 1, simpleMap, Options{
+			Waves: []Wave{{Iteration: 1, Aliens: 1, Faction: "red"}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("lone alien reproduces", func(t *testing.T) {
+		s, err := NewSimulation(10, 1, simpleMap, Options{
+			AlienNames:        []string{"Zorg"},
+			ReproductionTurns: 2,
+		})
+		require.NoError(t, err)
+		s.alienPositions = AlienPositions{"Zorg": "Clifton"}
+
+		s.Step()
+		assert.Len(t, s.alienPositions, 1)
+
+		s.Step()
+		assert.Equal(t, map[Alien]City{"Zorg": "Clifton", "Alien 1": "Clifton"}, s.alienPositions)
+
+		// the alien and its offspring coexist
+		s.Step()
+		assert.Equal(t, map[Alien]City{"Zorg": "Clifton", "Alien 1": "Clifton"}, s.alienPositions)
+		assert.Contains(t, s.worldMap, City("Clifton"))
+	})
+
+	t.Run("offspring of different aliens fight", func(t *testing.T) {
+		s, err := NewSimulation(10, 0, simpleMap, Options{})
+		require.NoError(t, err)
+		s.lineages = map[Alien]Alien{"Alien 3": "Alien 1", "Alien 4": "Alien 2"}
+
+		assert.False(t, s.enemies([]Alien{"Alien 1", "Alien 3"}))
+		assert.True(t, s.enemies([]Alien{"Alien 3", "Alien 4"}))
+		assert.True(t, s.enemies([]Alien{"Alien 1", "Alien 4"}))
+	})
+}
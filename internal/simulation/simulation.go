@@ -1,17 +1,16 @@
 package simulation
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
 	"strings"
 
-	_ "embed"
+	"github.com/maruqu/alien-invasion/internal/util"
 )
 
-//go:embed alien-names.txt
-var alienNames string
-
 // Simulation stores the state of the simulation.
 type Simulation struct {
 	iterationCounter int
@@ -22,32 +21,281 @@ type Simulation struct {
 
 	// alienPositions maps alien name to its current position (city).
 	alienPositions map[Alien]City
+
+	factions []Faction
+
+	// alienFactions maps alien name to its faction, aliens without a faction fight every other alien.
+	alienFactions map[Alien]string
+
+	// collisionRule applied when aliens meet, DefaultCollisionRule if nil.
+	collisionRule *CollisionRule
+
+	// squadPositions maps human squad name to its current position (city).
+	squadPositions map[Squad]City
+	squadsCount    int
+
+	// squadTransit maps human squad name to its journey along a road longer than one iteration.
+	squadTransit map[Squad]journey
+
+	squadStrategy SquadStrategy
+	squadDeath    float64
+
+	namer *alienNamer
+
+	// waves of aliens landing in the later iterations
+	waves []Wave
+
+	// reproduction is a number of turns an alien has to spend alone in a city to spawn another alien.
+	reproduction int
+
+	// loneTurns maps alien name to a number of consecutive turns spent alone in a city.
+	loneTurns map[Alien]int
+
+	// lineages maps aliens without a faction spawned by other aliens to the first alien of their lineage,
+	// so that an alien never fights its offspring.
+	lineages map[Alien]Alien
+
+	// fuel maps alien name to its remaining fuel, nil if fuel is unlimited.
+	fuel       map[Alien]int
+	fuelBudget int
+
+	// stalled aliens ran out of fuel.
+	stalled map[Alien]struct{}
+
+	// transit maps alien name to its journey along a road longer than one iteration.
+	transit map[Alien]journey
+	reroute bool
+
+	// the simulation stops when at most stopAliens aliens or stopCities cities are left
+	stopAliens int
+	stopCities int
+
+	// rnd is the random number generator of the simulation, its state is saved in checkpoints.
+	rnd    *rand.Rand
+	source *util.Source
+
+	// initialMap is the world map before the invasion.
+	initialMap WorldMap
+
+	// resumed is set if the simulation was restored from a checkpoint.
+	resumed bool
+
+	// saveCheckpoint is called by Run with a checkpoint every checkpointEvery iterations.
+	checkpointEvery int
+	saveCheckpoint  func(data []byte) error
+
+	// reportProgress is called by Run with the progress after every iteration.
+	reportProgress func(progress Progress)
+
+	// history of the iterations recorded for rewinding, nil if the simulation is not recorded.
+	history *history
 }
 
-// NewSimulation returned initialized Simulation structure with aliens randomly placed on the map.
-func NewSimulation(iterationLimit, aliensCount int, worldMap WorldMap) (*Simulation, error) {
+// Options configure the simulation.
+type Options struct {
+	// AlienNames is a source of alien names, the embedded list of alien names by default.
+	AlienNames []string
+
+	// AlienNaming selects how aliens are named, AlienNamingList by default.
+	AlienNaming AlienNaming
+
+	// SafeNames replaces whitespaces and special characters in alien names,
+	// so that every name is a single word in the log.
+	SafeNames bool
+
+	// Factions split aliens into teams. Aliens of the same faction coexist in a city,
+	// aliens of different factions fight. Sizes of the factions have to sum up to the aliens count.
+	// Every alien fights every other alien by default.
+	Factions []Faction
+
+	// CollisionRule applied when aliens meet in a city, DefaultCollisionRule if nil.
+	CollisionRule *CollisionRule
+
+	// Squads is a number of human resistance squads randomly placed on the map.
+	Squads int
+
+	// SquadStrategy selects how the squads move, SquadStrategyRandom by default.
+	SquadStrategy SquadStrategy
+
+	// SquadDeathChance is a probability that a squad dies in a fight with an alien.
+	SquadDeathChance float64
+
+	// Waves of alien reinforcements landing in the later iterations.
+	Waves []Wave
+
+	// ReproductionTurns is a number of consecutive turns an alien has to spend alone in a city
+	// to spawn another alien of the same faction. Zero disables reproduction.
+	ReproductionTurns int
+
+	// Fuel is a budget of every alien spent on travelling along the roads (road cost per move, see Neighbors.Cost).
+	// Aliens which cannot afford any road stall. Zero means unlimited fuel.
+	Fuel int
+
+	// Reroute turns aliens travelling to a destroyed city back to the city they came from.
+	// By default such aliens are stranded on the road and die there, which is logged.
+	Reroute bool
+
+	// Placement selects how the initial aliens are placed on the map, PlacementRandom by default.
+	Placement Placement
+
+	// LandingZones is a number of landing zones of PlacementClustered, one by default.
+	LandingZones int
+
+	// FixedPlacement lists the initial aliens along with their cities in place of Placement.
+	// Its length has to match the aliens count.
+	FixedPlacement []PlacedAlien
+
+	// StopAliens stops the simulation when at most this number of aliens is left
+	// and no more waves are pending. The simulation stops without aliens by default.
+	StopAliens int
+
+	// StopCities stops the simulation when at most this number of cities is left.
+	// The simulation stops when the whole world is destroyed by default.
+	StopCities int
+
+	// Seed of the random number generator of the simulation.
+	// A seed is drawn from the global random number generator by default.
+	Seed *int64
+}
+
+// NewSimulation returned initialized Simulation structure with aliens placed on the map according to the placement.
+func NewSimulation(iterationLimit, aliensCount int, worldMap WorldMap, options Options) (*Simulation, error) {
 	if len(worldMap) == 0 {
 		return nil, fmt.Errorf("map cannot be empty")
 	}
 
-	alienPositions := generateAlienPlacement(aliensCount, worldMap)
+	if err := worldMap.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid map: %w", err)
+	}
+
+	if options.StopAliens < 0 || options.StopCities < 0 {
+		return nil, fmt.Errorf("stop conditions cannot be negative")
+	}
+
+	if err := options.validatePlacement(aliensCount, worldMap); err != nil {
+		return nil, err
+	}
+
+	seed := rand.Int63()
+	if options.Seed != nil {
+		seed = *options.Seed
+	}
+	source := util.NewSource(seed)
+	rnd := rand.New(source)
+
+	namer, err := newAlienNamer(aliensCount, options, rnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var aliens []Alien
+	if len(options.FixedPlacement) > 0 {
+		for _, placed := range options.FixedPlacement {
+			namer.reserve(placed.Alien)
+			aliens = append(aliens, placed.Alien)
+		}
+	} else {
+		aliens = namer.take(aliensCount)
+	}
+
+	if options.CollisionRule != nil {
+		if err := options.CollisionRule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid collision rule: %w", err)
+		}
+	}
+
+	alienFactions, err := assignFactions(aliens, options.Factions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := options.validateSquads(); err != nil {
+		return nil, err
+	}
+
+	if err := options.validateReinforcements(); err != nil {
+		return nil, err
+	}
+
+	if err := options.validateFuel(); err != nil {
+		return nil, err
+	}
 
 	s := &Simulation{
 		iterationCounter: 0,
 		iterationLimit:   iterationLimit,
 		worldMap:         copyMap(worldMap),
-		alienPositions:   alienPositions,
+		factions:         append([]Faction(nil), options.Factions...),
+		alienFactions:    alienFactions,
+		collisionRule:    options.CollisionRule,
+		squadsCount:      options.Squads,
+		squadStrategy:    options.SquadStrategy,
+		squadDeath:       options.SquadDeathChance,
+		namer:            namer,
+		waves:            options.Waves,
+		reproduction:     options.ReproductionTurns,
+		loneTurns:        make(map[Alien]int),
+		fuelBudget:       options.Fuel,
+		stalled:          make(map[Alien]struct{}),
+		reroute:          options.Reroute,
+		stopAliens:       options.StopAliens,
+		stopCities:       options.StopCities,
+		rnd:              rnd,
+		source:           source,
+		initialMap:       copyMap(worldMap),
+	}
+
+	s.squadPositions = s.placeSquads(options.Squads)
+
+	if len(options.FixedPlacement) > 0 {
+		s.alienPositions = make(AlienPositions, len(aliens))
+		for _, placed := range options.FixedPlacement {
+			s.alienPositions[placed.Alien] = placed.City
+		}
+	} else {
+		s.alienPositions = s.placeAliens(aliens, options.Placement, options.LandingZones)
+	}
+
+	if options.Fuel > 0 {
+		s.fuel = make(map[Alien]int, len(aliens))
+		for _, alien := range aliens {
+			s.fuel[alien] = options.Fuel
+		}
 	}
 
 	return s, nil
 }
 
 // Run starts simulation, executes steps until the stop condition is met and returns a WorldMap as result.
-func (s *Simulation) Run() (WorldMap, error) {
-	log.Println("Alien invasion started!")
+// Checkpoints are saved periodically if configured (see SetCheckpoint) and progress is reported after
+// every iteration (see SetProgress). When the context is cancelled, the simulation stops after the current
+// iteration, saves a checkpoint if configured and returns the partial result along with the context error.
+func (s *Simulation) Run(ctx context.Context) (WorldMap, error) {
+	if s.resumed {
+		log.Printf("Alien invasion resumed at iteration %d!", s.iterationCounter)
+	} else {
+		log.Println("Alien invasion started!")
+	}
 
 	for !s.ShouldStop() {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Alien invasion interrupted at iteration %d!", s.iterationCounter)
+
+			if err := s.saveState(); err != nil {
+				return nil, err
+			}
+			return s.worldMap, err
+		}
+
 		s.Step()
+
+		if s.reportProgress != nil {
+			s.reportProgress(s.progress())
+		}
+
+		if err := s.checkpoint(); err != nil {
+			return nil, err
+		}
 	}
 
 	log.Println("Alien invasion finished!")
@@ -55,82 +303,142 @@ func (s *Simulation) Run() (WorldMap, error) {
 	return s.worldMap, nil
 }
 
+// Progress of a running simulation.
+type Progress struct {
+	Iteration      int
+	IterationLimit int
+	Aliens         int
+	Cities         int
+}
+
+// SetProgress makes Run pass the progress of the simulation to a provided function after every iteration.
+func (s *Simulation) SetProgress(report func(progress Progress)) {
+	s.reportProgress = report
+}
+
+// progress returns the current progress of the simulation, aliens in transit included.
+func (s *Simulation) progress() Progress {
+	return Progress{
+		Iteration:      s.iterationCounter,
+		IterationLimit: s.iterationLimit,
+		Aliens:         len(s.alienPositions) + len(s.transit),
+		Cities:         len(s.worldMap),
+	}
+}
+
+// InitialMap returns the world map before the invasion.
+func (s *Simulation) InitialMap() WorldMap {
+	return s.initialMap
+}
+
 // ShouldStop returns true if a stop condition is met.
 func (s *Simulation) ShouldStop() bool {
-	return s.iterationCounter >= s.iterationLimit || len(s.alienPositions) == 0 || len(s.worldMap) == 0
+	return s.iterationCounter >= s.iterationLimit ||
+		(len(s.alienPositions)+len(s.transit) <= s.stopAliens && !s.pendingWaves()) ||
+		len(s.worldMap) <= s.stopCities ||
+		s.immobile()
+}
+
+// FactionSurvivors returns a number of surviving aliens of every faction in the order of the factions.
+func (s *Simulation) FactionSurvivors() []FactionSurvivors {
+	survivors := make(map[string]int, len(s.factions))
+	for alien := range s.alienPositions {
+		survivors[s.alienFactions[alien]] += 1
+	}
+	for alien := range s.transit {
+		survivors[s.alienFactions[alien]] += 1
+	}
+
+	result := make([]FactionSurvivors, len(s.factions))
+	for i, faction := range s.factions {
+		result[i] = FactionSurvivors{Faction: faction, Survivors: survivors[faction.Name]}
+	}
+
+	return result
 }
 
-// Step moves all aliens on the map and evaluate the rules.
+// Step moves all aliens and squads on the map and evaluate the rules.
+// Aliens travelling along roads longer than one iteration are evaluated when they reach their destinations.
+// Changes made by the step are recorded if the simulation is recorded (see Record).
 func (s *Simulation) Step() {
+	s.beginDelta()
+	defer s.endDelta()
+
 	// evaluate the rules for the initial alien placement
 	if s.iterationCounter == 0 {
+		s.evaluateSquads()
 		s.evaluateRules()
 	}
 
+	s.landWaves()
+
+	previousPositions := s.alienPositions
+	s.updateSquadPositions()
+	arrived := s.advanceTransit()
 	s.updateAlienPositions()
+	for alien, city := range arrived {
+		s.alienPositions[alien] = city
+	}
+	if s.rule().RoadFights {
+		s.evaluateRoadFights(previousPositions)
+	}
+	s.evaluateSquads()
 	s.evaluateRules()
+	s.reproduce()
 	s.iterationCounter += 1
 }
 
-// generateAlienPlacement randomly assigns positions on the map for the provided alien count.
-func generateAlienPlacement(aliensCount int, worldMap WorldMap) AlienPositions {
-	aliens := getAliens(aliensCount)
+// assignFactions assigns consecutive aliens to the factions according to their sizes.
+func assignFactions(aliens []Alien, factions []Faction) (map[Alien]string, error) {
+	if len(factions) == 0 {
+		return nil, nil
+	}
 
-	cities := make([]City, 0, len(worldMap))
-	for city := range worldMap {
-		cities = append(cities, city)
+	result := make(map[Alien]string, len(aliens))
+	names := make(map[string]struct{}, len(factions))
+
+	for _, faction := range factions {
+		if faction.Name == "" || faction.Size < 0 {
+			return nil, fmt.Errorf("invalid faction: %q of size %d", faction.Name, faction.Size)
+		}
+		if _, ok := names[faction.Name]; ok {
+			return nil, fmt.Errorf("duplicated faction: %s", faction.Name)
+		}
+		names[faction.Name] = struct{}{}
+
+		for i := 0; i < faction.Size && len(result) < len(aliens); i++ {
+			result[aliens[len(result)]] = faction.Name
+		}
 	}
 
-	alienPositions := make(AlienPositions, len(aliens))
-	for _, alien := range aliens {
-		randomCityIdx := rand.Intn(len(cities))
-		alienPositions[alien] = cities[randomCityIdx]
+	if total := factionsSize(factions); total != len(aliens) {
+		return nil, fmt.Errorf("faction sizes (%d) do not match aliens count (%d)", total, len(aliens))
 	}
 
-	return alienPositions
+	return result, nil
 }
 
-// getAliens returns a slice of aliens with a provided count.
-// Pre-generated names aer returned for up to 75 aliens.
-// For greater counts, aliens are named ["Alien 1", "Alien 2",...]
-func getAliens(count int) []Alien {
-	result := make([]Alien, count)
-
-	if count <= 75 {
-		names := strings.Split(strings.TrimSpace(alienNames), "\n")
-
-		for i := 0; i < count; i++ {
-			result[i] = Alien(names[i])
-		}
-	} else {
-		for i := 0; i < count; i++ {
-			result[i] = Alien(fmt.Sprintf("Alien %d", i+1))
-		}
+// factionsSize returns a total number of aliens in the factions.
+func factionsSize(factions []Faction) int {
+	total := 0
+	for _, faction := range factions {
+		total += faction.Size
 	}
-
-	return result
+	return total
 }
 
 // updateAlienPositions calculates updated alien positions using connections between the cities.
 func (s *Simulation) updateAlienPositions() {
 	updatedAlienPositions := make(AlienPositions)
 
-	for alien, city := range s.alienPositions {
-		cityNeighbors := s.worldMap[city]
+	for _, alien := range sortedAliens(s.alienPositions) {
+		city := s.alienPositions[alien]
+		neighbors := s.worldMap[city]
 
 		// pick random direction
-		possibleDirections := make([]City, 0, 4)
-		if cityNeighbors.North != "" {
-			possibleDirections = append(possibleDirections, cityNeighbors.North)
-		}
-		if cityNeighbors.South != "" {
-			possibleDirections = append(possibleDirections, cityNeighbors.South)
-		}
-		if cityNeighbors.East != "" {
-			possibleDirections = append(possibleDirections, cityNeighbors.East)
-		}
-		if cityNeighbors.West != "" {
-			possibleDirections = append(possibleDirections, cityNeighbors.West)
+		possibleDirections := neighbors.directions()
+		if s.fuel != nil {
+			possibleDirections = s.affordable(alien, neighbors, possibleDirections)
 		}
 
 		// check if alien is trapped
@@ -139,69 +447,305 @@ func (s *Simulation) updateAlienPositions() {
 			continue
 		}
 
-		randomIdx := rand.Intn(len(possibleDirections))
-		updatedAlienPositions[alien] = possibleDirections[randomIdx]
+		direction := possibleDirections[s.rnd.Intn(len(possibleDirections))]
+		length := neighbors.Length(direction)
+
+		if s.fuel != nil {
+			s.recordAlien(alien)
+			s.fuel[alien] -= neighbors.Cost(direction)
+		}
+
+		if length > 1 {
+			s.depart(alien, city, neighbors.Road(direction), length)
+			continue
+		}
+
+		updatedAlienPositions[alien] = neighbors.Road(direction)
 	}
 
+	s.recordAlienPositions()
 	s.alienPositions = updatedAlienPositions
 }
 
-// evaluateRules check for cities where two or more aliens are currently located in.
-// Such cities and aliens are deleted from the simulation state.
+// evaluateRules check for cities where aliens are currently located in and applies the collision rule.
+// Aliens killed in fights and destroyed cities are deleted from the simulation state.
 func (s *Simulation) evaluateRules() {
-	// check for alien fights
-	cityAlienCount := make(map[City]int)
-	for _, city := range s.alienPositions {
-		cityAlienCount[city] += 1
-	}
-
-	// destroy aliens and cities
-	for city, count := range cityAlienCount {
-		if count >= 2 {
-			// delete aliens
-			var destroyedAlienNames []string
-			for alien, position := range s.alienPositions {
-				if position == city {
-					destroyedAlienNames = append(destroyedAlienNames, string(alien))
-					delete(s.alienPositions, alien)
-				}
+	rule := s.rule()
+
+	cityAliens := make(map[City][]Alien)
+	for alien, city := range s.alienPositions {
+		cityAliens[city] = append(cityAliens[city], alien)
+	}
+
+	// cities and aliens are sorted, so that the random outcomes are reproducible
+	cities := make([]City, 0, len(cityAliens))
+	for city, aliens := range cityAliens {
+		sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i] < cities[j] })
+
+	for _, city := range cities {
+		aliens := cityAliens[city]
+
+		if rule.Capacity > 0 && len(aliens) > rule.Capacity {
+			s.killAliens(aliens)
+			s.destroyCity(city)
+			log.Printf("%s has collapsed under %s!", city, joinAliens(aliens))
+			continue
+		}
+
+		if len(aliens) == 1 {
+			if defense := s.worldMap[city].Defense; defense > 0 && s.rnd.Float64() < float64(defense)/float64(defense+1) {
+				s.killAliens(aliens)
+				log.Printf("%s has been killed by defenders of %s!", aliens[0], city)
 			}
+			continue
+		}
+
+		if len(aliens) < rule.MinFighters || !s.enemies(aliens) {
+			continue
+		}
+
+		if s.rnd.Float64() < rule.DestructionChance {
+			s.killAliens(aliens)
+
+			neighbors := s.worldMap[city]
+			if hp := neighbors.hp() - (len(aliens) - 1); hp > 0 {
+				s.recordCity(city)
+				neighbors.HP = hp
+				s.worldMap[city] = neighbors
+				log.Printf("%s withstood an attack of %s (%d hp left)!", city, joinAliens(aliens), hp)
+				continue
+			}
+
+			s.destroyCity(city)
+			log.Printf("%s has been destroyed by %s!", city, joinAliens(aliens))
+			continue
+		}
+
+		if rule.RandomSurvivor {
+			survivorIdx := s.rnd.Intn(len(aliens))
+			survivor := aliens[survivorIdx]
+			aliens = append(aliens[:survivorIdx:survivorIdx], aliens[survivorIdx+1:]...)
+
+			s.killAliens(aliens)
+			log.Printf("%s survived a fight with %s in %s!", survivor, joinAliens(aliens), city)
+			continue
+		}
+
+		s.killAliens(aliens)
+		log.Printf("%s have been killed in a fight in %s!", joinAliens(aliens), city)
+	}
+}
+
+// evaluateRoadFights finds enemy aliens which swapped their positions travelling along the same road.
+// The aliens are killed and the road is destroyed along with the cities at its ends if required by the rule.
+func (s *Simulation) evaluateRoadFights(previousPositions AlienPositions) {
+	// aliens travelling along every road, the road is identified by its ends in the alphabetical order
+	type road struct{ from, to City }
+	roadAliens := make(map[road][2][]Alien)
+
+	for alien, city := range s.alienPositions {
+		// aliens arriving from long roads do not swap cities with anyone
+		previous, ok := previousPositions[alien]
+		if !ok || previous == city {
+			continue
+		}
+
+		r, direction := road{previous, city}, 0
+		if city < previous {
+			r, direction = road{city, previous}, 1
+		}
+
+		aliens := roadAliens[r]
+		aliens[direction] = append(aliens[direction], alien)
+		roadAliens[r] = aliens
+	}
+
+	roads := make([]road, 0, len(roadAliens))
+	for r, aliens := range roadAliens {
+		if len(aliens[0]) > 0 && len(aliens[1]) > 0 {
+			roads = append(roads, r)
+		}
+	}
+	sort.Slice(roads, func(i, j int) bool {
+		return roads[i].from < roads[j].from || (roads[i].from == roads[j].from && roads[i].to < roads[j].to)
+	})
+
+	for _, r := range roads {
+		aliens := append(roadAliens[r][0], roadAliens[r][1]...)
+		sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
 
-			// delete city
-			delete(s.worldMap, city)
-
-			// delete all roads leading to this city
-			for c, neighbors := range s.worldMap {
-				if neighbors.North != "" && neighbors.North == city {
-					neighbors.North = ""
-				}
-				if neighbors.South != "" && neighbors.South == city {
-					neighbors.South = ""
-				}
-				if neighbors.East != "" && neighbors.East == city {
-					neighbors.East = ""
-				}
-				if neighbors.West != "" && neighbors.West == city {
-					neighbors.West = ""
-				}
-
-				s.worldMap[c] = neighbors
+		if !s.enemies(aliens) {
+			continue
+		}
+
+		s.killAliens(aliens)
+
+		if s.rule().RoadFightsDestroyCities {
+			for _, city := range []City{r.from, r.to} {
+				s.killAliens(s.aliensIn(city))
+				s.destroyCity(city)
 			}
 
-			log.Printf(
-				"%s has been destroyed by %s and %s!",
-				city,
-				strings.Join(destroyedAlienNames[:len(destroyedAlienNames)-1], ", "),
-				destroyedAlienNames[len(destroyedAlienNames)-1],
-			)
+			log.Printf("%s and %s have been destroyed by %s fighting on the road between them!", r.from, r.to, joinAliens(aliens))
+			continue
 		}
+
+		s.destroyRoad(r.from, r.to)
+		log.Printf("Road between %s and %s has been destroyed by %s!", r.from, r.to, joinAliens(aliens))
+	}
+}
+
+// enemies reports whether there are aliens of at least two different factions among the provided aliens.
+func (s *Simulation) enemies(aliens []Alien) bool {
+	for _, alien := range aliens[1:] {
+		if s.side(alien) != s.side(aliens[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// killAliens deletes aliens along with all their state from the simulation.
+func (s *Simulation) killAliens(aliens []Alien) {
+	for _, alien := range aliens {
+		s.recordAlien(alien)
+		delete(s.alienPositions, alien)
+		delete(s.alienFactions, alien)
+		delete(s.transit, alien)
+		delete(s.fuel, alien)
+		delete(s.stalled, alien)
+		delete(s.loneTurns, alien)
+		delete(s.lineages, alien)
+	}
+}
+
+// aliensIn returns aliens located in a city in the alphabetical order.
+func (s *Simulation) aliensIn(city City) []Alien {
+	var aliens []Alien
+	for alien, position := range s.alienPositions {
+		if position == city {
+			aliens = append(aliens, alien)
+		}
+	}
+	sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
+	return aliens
+}
+
+// destroyCity deletes a city along with all roads leading to it.
+func (s *Simulation) destroyCity(city City) {
+	s.recordCity(city)
+	delete(s.worldMap, city)
+
+	// squads defending the city are killed as well
+	for squad, position := range s.squadPositions {
+		if position == city {
+			s.recordSquad(squad)
+			delete(s.squadPositions, squad)
+		}
+	}
+
+	for c := range s.worldMap {
+		s.removeRoads(c, city)
+	}
+}
+
+// destroyRoad deletes roads connecting two cities in both directions.
+func (s *Simulation) destroyRoad(a, b City) {
+	s.removeRoads(a, b)
+	s.removeRoads(b, a)
+}
+
+// removeRoads deletes roads leading from one city to another.
+func (s *Simulation) removeRoads(from, to City) {
+	neighbors, ok := s.worldMap[from]
+	if !ok {
+		return
+	}
+
+	var directions []Direction
+	for _, direction := range neighbors.directions() {
+		if neighbors.Road(direction) == to {
+			directions = append(directions, direction)
+		}
+	}
+	if len(directions) == 0 {
+		return
+	}
+
+	s.recordCity(from)
+	for _, direction := range directions {
+		neighbors.SetRoad(direction, "")
+		delete(neighbors.Lengths, direction)
+		delete(neighbors.Costs, direction)
+		delete(neighbors.OneWay, direction)
+	}
+	if len(neighbors.Lengths) == 0 {
+		neighbors.Lengths = nil
+	}
+	if len(neighbors.Costs) == 0 {
+		neighbors.Costs = nil
+	}
+	if len(neighbors.OneWay) == 0 {
+		neighbors.OneWay = nil
+	}
+
+	s.worldMap[from] = neighbors
+}
+
+// sortedAliens returns names of the aliens in the alphabetical order.
+func sortedAliens(alienPositions AlienPositions) []Alien {
+	aliens := make([]Alien, 0, len(alienPositions))
+	for alien := range alienPositions {
+		aliens = append(aliens, alien)
+	}
+	sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
+	return aliens
+}
+
+// joinAliens returns alien names joined in a sentence ("A, B and C").
+func joinAliens(aliens []Alien) string {
+	names := make([]string, len(aliens))
+	for i, alien := range aliens {
+		names[i] = string(alien)
+	}
+
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// rule returns the collision rule applied by the simulation.
+func (s *Simulation) rule() CollisionRule {
+	if s.collisionRule != nil {
+		return *s.collisionRule
+	}
+	return DefaultCollisionRule
+}
+
+// side returns an identifier shared only by the aliens which do not fight each other.
+func (s *Simulation) side(alien Alien) string {
+	if faction, ok := s.alienFactions[alien]; ok {
+		return "faction:" + faction
+	}
+	return "alien:" + string(s.lineage(alien))
+}
+
+// lineage returns the first alien of the lineage of an alien, the alien itself if it was not spawned.
+func (s *Simulation) lineage(alien Alien) Alien {
+	if first, ok := s.lineages[alien]; ok {
+		return first
 	}
+	return alien
 }
 
 func copyMap(worldMap WorldMap) WorldMap {
 	newWorldMap := make(WorldMap, len(worldMap))
 	for c, n := range worldMap {
-		newWorldMap[c] = n
+		newWorldMap[c] = n.copy()
 	}
 	return newWorldMap
 }
@@ -1,7 +1,10 @@
 package simulation
 
 import (
+	"context"
 	_ "embed"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,9 +48,24 @@ var (
 	}
 )
 
+// newTestSimulation creates a simulation of a world map without aliens through NewSimulation,
+// seeded with 1 unless a seed is provided. Tests put the aliens on the map afterwards.
+func newTestSimulation(t *testing.T, iterationLimit int, worldMap WorldMap, options Options) *Simulation {
+	t.Helper()
+
+	if options.Seed == nil {
+		seed := int64(1)
+		options.Seed = &seed
+	}
+
+	s, err := NewSimulation(iterationLimit, 0, worldMap, options)
+	require.NoError(t, err)
+	return s
+}
+
 func Test_NewSimulation(t *testing.T) {
 	t.Run("aliens placed on map correctly", func(t *testing.T) {
-		s, err := NewSimulation(10, 3, simpleMap)
+		s, err := NewSimulation(10, 3, simpleMap, Options{})
 		require.NoError(t, err)
 
 		assert.Len(t, s.alienPositions, 3)
@@ -58,15 +76,300 @@ func Test_NewSimulation(t *testing.T) {
 	})
 }
 
+func Test_Validate(t *testing.T) {
+	t.Run("symmetric roads accepted", func(t *testing.T) {
+		assert.NoError(t, starMap.Validate())
+	})
+
+	t.Run("asymmetric road rejected", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson"},
+			"Pinson":   Neighbors{},
+		}
+		assert.Error(t, worldMap.Validate())
+
+		_, err := NewSimulation(10, 1, worldMap, Options{})
+		assert.Error(t, err)
+	})
+
+	t.Run("one-way road accepted", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson", OneWay: map[Direction]bool{South: true}},
+			"Pinson":   Neighbors{},
+		}
+		assert.NoError(t, worldMap.Validate())
+	})
+
+	t.Run("diagonal roads accepted", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{SouthEast: "Pinson"},
+			"Pinson":   Neighbors{NorthWest: "Talihina"},
+		}
+		assert.NoError(t, worldMap.Validate())
+	})
+
+	t.Run("named exit matched by any road leading back", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{Exits: map[Direction]City{"portal": "Pinson"}},
+			"Pinson":   Neighbors{North: "Talihina"},
+		}
+		assert.NoError(t, worldMap.Validate())
+	})
+
+	t.Run("road to unknown city rejected", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson", OneWay: map[Direction]bool{South: true}},
+		}
+		assert.Error(t, worldMap.Validate())
+	})
+
+	t.Run("negative and duplicate coordinates rejected", func(t *testing.T) {
+		worldMap := WorldMap{
+			"Talihina": Neighbors{Coordinates: &Coordinates{X: -1, Y: 0}},
+		}
+		assert.Error(t, worldMap.Validate())
+
+		worldMap = WorldMap{
+			"Talihina": Neighbors{Coordinates: &Coordinates{X: 1, Y: 1}},
+			"Pinson":   Neighbors{Coordinates: &Coordinates{X: 1, Y: 1}},
+		}
+		assert.Error(t, worldMap.Validate())
+	})
+}
+
+func Test_AlienNames(t *testing.T) {
+	getAliens := func(count int, options Options) ([]Alien, error) {
+		namer, err := newAlienNamer(count, options, rand.New(rand.NewSource(1)))
+		if err != nil {
+			return nil, err
+		}
+		return namer.take(count), nil
+	}
+
+	t.Run("names taken from the source", func(t *testing.T) {
+		aliens, err := getAliens(2, Options{AlienNames: []string{"Zorg", "Blip", "Quux"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Alien{"Zorg", "Blip"}, aliens)
+	})
+
+	t.Run("generated names are unique beyond the embedded list", func(t *testing.T) {
+		aliens, err := getAliens(500, Options{AlienNaming: AlienNamingGenerate})
+		require.NoError(t, err)
+
+		assert.Len(t, aliens, 500)
+	})
+
+	t.Run("safe names are single words", func(t *testing.T) {
+		for _, naming := range AlienNamings {
+			aliens, err := getAliens(100, Options{AlienNaming: naming, SafeNames: true})
+			require.NoError(t, err)
+
+			for _, alien := range aliens {
+				assert.Len(t, strings.Fields(string(alien)), 1, "%s", alien)
+			}
+		}
+	})
+
+	t.Run("names clashing after making them safe rejected", func(t *testing.T) {
+		_, err := getAliens(2, Options{AlienNames: []string{"Zorg Blip", "Zorg_Blip"}, SafeNames: true})
+		assert.Error(t, err)
+	})
+}
+
+func Test_Factions(t *testing.T) {
+	factions := []Faction{{Name: "red", Size: 2}, {Name: "blue", Size: 1}}
+
+	t.Run("aliens of the same faction coexist", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Centercity",
+			"Alien 2": "Centercity",
+		}
+		s.alienFactions = map[Alien]string{"Alien 1": "red", "Alien 2": "red"}
+
+		s.evaluateRules()
+
+		assert.Equal(t, starMap, s.worldMap)
+		assert.Len(t, s.alienPositions, 2)
+	})
+
+	t.Run("aliens of different factions fight", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Centercity",
+			"Alien 2": "Centercity",
+			"Alien 3": "Centercity",
+			"Alien 4": "Northcity",
+		}
+		s.factions = factions
+		s.alienFactions = map[Alien]string{"Alien 1": "red", "Alien 2": "red", "Alien 3": "blue", "Alien 4": "blue"}
+
+		s.evaluateRules()
+
+		assert.NotContains(t, s.worldMap, City("Centercity"))
+		assert.Equal(t, map[Alien]City{"Alien 4": "Northcity"}, s.alienPositions)
+		assert.Equal(t, []FactionSurvivors{
+			{Faction: factions[0], Survivors: 0},
+			{Faction: factions[1], Survivors: 1},
+		}, s.FactionSurvivors())
+	})
+
+	t.Run("aliens assigned to factions", func(t *testing.T) {
+		s, err := NewSimulation(10, 3, simpleMap, Options{Factions: factions})
+		require.NoError(t, err)
+
+		assert.Len(t, s.alienFactions, 3)
+		assert.Equal(t, []FactionSurvivors{
+			{Faction: factions[0], Survivors: 2},
+			{Faction: factions[1], Survivors: 1},
+		}, s.FactionSurvivors())
+	})
+
+	t.Run("faction sizes have to match aliens count", func(t *testing.T) {
+		_, err := NewSimulation(10, 4, simpleMap, Options{Factions: factions})
+		assert.Error(t, err)
+	})
+}
+
+func Test_CollisionRule(t *testing.T) {
+	collide := func(rule CollisionRule, alienPositions AlienPositions) *Simulation {
+		s := newTestSimulation(t, 0, starMap, Options{CollisionRule: &rule})
+		s.alienPositions = alienPositions
+		s.evaluateRules()
+		return s
+	}
+
+	t.Run("fight requires minimal number of fighters", func(t *testing.T) {
+		rule := DefaultCollisionRule
+		rule.MinFighters = 3
+
+		s := collide(rule, AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity"})
+		assert.Len(t, s.alienPositions, 2)
+		assert.Contains(t, s.worldMap, City("Centercity"))
+
+		s = collide(rule, AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity", "Alien 3": "Centercity"})
+		assert.Empty(t, s.alienPositions)
+		assert.NotContains(t, s.worldMap, City("Centercity"))
+	})
+
+	t.Run("city left standing", func(t *testing.T) {
+		rule := CollisionRule{MinFighters: 2, DestructionChance: 0}
+
+		s := collide(rule, AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity"})
+		assert.Empty(t, s.alienPositions)
+		assert.Equal(t, starMap, s.worldMap)
+	})
+
+	t.Run("random survivor of a fight", func(t *testing.T) {
+		rule := CollisionRule{MinFighters: 2, RandomSurvivor: true}
+
+		s := collide(rule, AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity", "Alien 3": "Centercity"})
+		assert.Len(t, s.alienPositions, 1)
+		for _, city := range s.alienPositions {
+			assert.Equal(t, City("Centercity"), city)
+		}
+		assert.Equal(t, starMap, s.worldMap)
+	})
+
+	t.Run("city collapses over capacity", func(t *testing.T) {
+		rule := DefaultCollisionRule
+		rule.Capacity = 2
+
+		s := newTestSimulation(t, 0, starMap, Options{CollisionRule: &rule})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Centercity",
+			"Alien 2": "Centercity",
+			"Alien 3": "Centercity",
+		}
+		s.alienFactions = map[Alien]string{"Alien 1": "red", "Alien 2": "red", "Alien 3": "red"}
+		s.evaluateRules()
+
+		assert.Empty(t, s.alienPositions)
+		assert.NotContains(t, s.worldMap, City("Centercity"))
+	})
+
+	t.Run("aliens swapping cities fight on the road", func(t *testing.T) {
+		for _, destroyCities := range []bool{false, true} {
+			rule := DefaultCollisionRule
+			rule.RoadFights = true
+			rule.RoadFightsDestroyCities = destroyCities
+
+			worldMap := WorldMap{
+				"Talihina": Neighbors{South: "Pinson"},
+				"Pinson":   Neighbors{North: "Talihina"},
+			}
+			s := newTestSimulation(t, 1, worldMap, Options{CollisionRule: &rule})
+			s.alienPositions = AlienPositions{"Alien 1": "Talihina", "Alien 2": "Pinson"}
+			s.Step()
+
+			assert.Empty(t, s.alienPositions)
+			if destroyCities {
+				assert.Empty(t, s.worldMap)
+			} else {
+				assert.Equal(t, WorldMap{"Talihina": Neighbors{}, "Pinson": Neighbors{}}, s.worldMap)
+			}
+		}
+	})
+
+	t.Run("aliens swap cities without road fights", func(t *testing.T) {
+		s := newTestSimulation(t, 1, WorldMap{
+			"Talihina": Neighbors{South: "Pinson"},
+			"Pinson":   Neighbors{North: "Talihina"},
+		}, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina", "Alien 2": "Pinson"}
+		s.Step()
+
+		assert.Equal(t, map[Alien]City{"Alien 1": "Pinson", "Alien 2": "Talihina"}, s.alienPositions)
+	})
+
+	t.Run("city with hit points withstands fights", func(t *testing.T) {
+		worldMap := copyMap(starMap)
+		worldMap["Centercity"] = Neighbors{North: "Northcity", South: "Southcity", East: "Eastcity", West: "Westcity", HP: 3}
+
+		s := newTestSimulation(t, 0, worldMap, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity"}
+		s.evaluateRules()
+
+		assert.Empty(t, s.alienPositions)
+		assert.Equal(t, 2, s.worldMap["Centercity"].HP)
+
+		s.alienPositions = AlienPositions{"Alien 1": "Centercity", "Alien 2": "Centercity", "Alien 3": "Centercity"}
+		s.evaluateRules()
+
+		assert.NotContains(t, s.worldMap, City("Centercity"))
+	})
+
+	t.Run("defenders kill a lone alien", func(t *testing.T) {
+		worldMap := copyMap(starMap)
+		worldMap["Centercity"] = Neighbors{North: "Northcity", South: "Southcity", East: "Eastcity", West: "Westcity", Defense: 1}
+
+		killed := 0
+		for i := int64(0); i < 100; i++ {
+			seed := i
+			s := newTestSimulation(t, 0, worldMap, Options{Seed: &seed})
+			s.alienPositions = AlienPositions{"Alien 1": "Centercity"}
+			s.evaluateRules()
+
+			killed += 1 - len(s.alienPositions)
+		}
+
+		assert.Greater(t, killed, 0)
+		assert.Less(t, killed, 100)
+	})
+
+	t.Run("invalid rule rejected", func(t *testing.T) {
+		_, err := NewSimulation(10, 3, simpleMap, Options{CollisionRule: &CollisionRule{MinFighters: 1}})
+		assert.Error(t, err)
+	})
+}
+
 func Test_Simulation(t *testing.T) {
 	t.Run("aliens and city destroyed", func(t *testing.T) {
-		s := &Simulation{
-			iterationCounter: 0,
-			worldMap:         copyMap(starMap),
-			alienPositions: AlienPositions{
-				"Alien 1": "Centercity",
-				"Alien 2": "Centercity",
-			},
+		s := newTestSimulation(t, 0, starMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Centercity",
+			"Alien 2": "Centercity",
 		}
 
 		s.Step()
@@ -84,12 +387,9 @@ func Test_Simulation(t *testing.T) {
 	})
 
 	t.Run("alien takes an existing road", func(t *testing.T) {
-		s := &Simulation{
-			iterationCounter: 0,
-			worldMap:         copyMap(simpleMap),
-			alienPositions: AlienPositions{
-				"Alien 1": "Talihina",
-			},
+		s := newTestSimulation(t, 0, simpleMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Talihina",
 		}
 		s.Step()
 
@@ -98,13 +398,9 @@ func Test_Simulation(t *testing.T) {
 	})
 
 	t.Run("alien never visits an isolated city", func(t *testing.T) {
-		s := &Simulation{
-			iterationCounter: 0,
-			iterationLimit:   100,
-			worldMap:         copyMap(simpleMap),
-			alienPositions: AlienPositions{
-				"Alien 1": "Pinson",
-			},
+		s := newTestSimulation(t, 100, simpleMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Pinson",
 		}
 
 		visitedCities := make(map[City]struct{})
@@ -119,13 +415,9 @@ func Test_Simulation(t *testing.T) {
 	})
 
 	t.Run("alien does not move when trapped in an isolated city", func(t *testing.T) {
-		s := &Simulation{
-			iterationCounter: 0,
-			iterationLimit:   100,
-			worldMap:         copyMap(simpleMap),
-			alienPositions: AlienPositions{
-				"Alien 1": "Clifton",
-			},
+		s := newTestSimulation(t, 100, simpleMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Clifton",
 		}
 
 		s.Step()
@@ -134,13 +426,9 @@ func Test_Simulation(t *testing.T) {
 	})
 
 	t.Run("alien is able to travel in any valid direction", func(t *testing.T) {
-		s := &Simulation{
-			iterationCounter: 0,
-			iterationLimit:   100,
-			worldMap:         copyMap(starMap),
-			alienPositions: AlienPositions{
-				"Alien 1": "Centercity",
-			},
+		s := newTestSimulation(t, 100, starMap, Options{})
+		s.alienPositions = AlienPositions{
+			"Alien 1": "Centercity",
 		}
 
 		visitedCities := make(map[City]struct{})
@@ -154,10 +442,10 @@ func Test_Simulation(t *testing.T) {
 	})
 
 	t.Run("simulation runs until iteration limit is reached", func(t *testing.T) {
-		s, err := NewSimulation(100, 1, copyMap(simpleMap))
+		s, err := NewSimulation(100, 1, copyMap(simpleMap), Options{})
 		require.NoError(t, err)
 
-		result, err := s.Run()
+		result, err := s.Run(context.Background())
 		require.NoError(t, err)
 
 		assert.Equal(t, simpleMap, result)
@@ -165,10 +453,134 @@ func Test_Simulation(t *testing.T) {
 		assert.True(t, s.ShouldStop())
 	})
 
+	t.Run("cancelled simulation returns partial result", func(t *testing.T) {
+		s, err := NewSimulation(100, 1, copyMap(simpleMap), Options{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var progress []Progress
+		s.SetProgress(func(p Progress) {
+			progress = append(progress, p)
+			if p.Iteration == 10 {
+				cancel()
+			}
+		})
+
+		var checkpoints int
+		s.SetCheckpoint(100, func(data []byte) error {
+			checkpoints += 1
+			return nil
+		})
+
+		result, err := s.Run(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		assert.Equal(t, simpleMap, result)
+		assert.Equal(t, 10, s.iterationCounter)
+		assert.Equal(t, 1, checkpoints)
+		require.Len(t, progress, 10)
+		assert.Equal(t, Progress{Iteration: 10, IterationLimit: 100, Aliens: 1, Cities: len(simpleMap)}, progress[9])
+	})
+
 	t.Run("names with ids generated if more that 75 aliens", func(t *testing.T) {
-		s, err := NewSimulation(100, 76, copyMap(simpleMap))
+		s, err := NewSimulation(100, 76, copyMap(simpleMap), Options{})
 		require.NoError(t, err)
 
 		assert.Contains(t, s.alienPositions, Alien("Alien 1"))
 	})
 }
+
+func Test_KillAliens(t *testing.T) {
+	// assertDead checks that no state of a killed alien is left in the simulation
+	assertDead := func(t *testing.T, s *Simulation, alien Alien) {
+		assert.NotContains(t, s.alienPositions, alien)
+		assert.NotContains(t, s.alienFactions, alien)
+		assert.NotContains(t, s.transit, alien)
+		assert.NotContains(t, s.fuel, alien)
+		assert.NotContains(t, s.stalled, alien)
+		assert.NotContains(t, s.loneTurns, alien)
+		assert.NotContains(t, s.lineages, alien)
+	}
+
+	newSimulation := func(t *testing.T, worldMap WorldMap, options Options, positions AlienPositions) *Simulation {
+		options.Factions = []Faction{{Name: "red"}, {Name: "blue"}}
+		options.Fuel = 5
+		options.ReproductionTurns = 3
+
+		s, err := NewSimulation(10, 0, worldMap, options)
+		require.NoError(t, err)
+
+		faction := "red"
+		for _, alien := range sortedAliens(positions) {
+			require.NoError(t, s.PlaceAlien(alien, positions[alien], faction))
+			s.loneTurns[alien] = 1
+			s.stalled[alien] = struct{}{}
+			faction = "blue"
+		}
+		return s
+	}
+
+	t.Run("alien killed by a squad", func(t *testing.T) {
+		s := newSimulation(t, starMap, Options{}, AlienPositions{"Alien 1": "Centercity"})
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+
+		s.evaluateSquads()
+
+		assertDead(t, s, "Alien 1")
+	})
+
+	t.Run("aliens killed with cities destroyed by a road fight", func(t *testing.T) {
+		rule := DefaultCollisionRule
+		rule.RoadFights = true
+		rule.RoadFightsDestroyCities = true
+
+		worldMap := WorldMap{
+			"Talihina": Neighbors{South: "Pinson"},
+			"Pinson":   Neighbors{North: "Talihina"},
+		}
+		previous := AlienPositions{"Alien 1": "Talihina", "Alien 2": "Pinson", "Alien 3": "Talihina"}
+		s := newSimulation(t, worldMap, Options{CollisionRule: &rule}, previous)
+		s.alienPositions = AlienPositions{"Alien 1": "Pinson", "Alien 2": "Talihina", "Alien 3": "Talihina"}
+
+		s.evaluateRoadFights(previous)
+
+		assert.Empty(t, s.worldMap)
+		for _, alien := range sortedAliens(previous) {
+			assertDead(t, s, alien)
+		}
+	})
+
+	t.Run("alien stranded on a road", func(t *testing.T) {
+		s := newSimulation(t, simpleMap, Options{}, AlienPositions{"Alien 1": "Talihina"})
+		s.depart("Alien 1", "Talihina", "Pinson", 3)
+		delete(s.alienPositions, "Alien 1")
+		delete(s.worldMap, "Pinson")
+
+		s.advanceTransit()
+
+		assertDead(t, s, "Alien 1")
+	})
+}
+
+func Test_StopConditions(t *testing.T) {
+	t.Run("stops with few aliens left", func(t *testing.T) {
+		s, err := NewSimulation(10, 2, starMap, Options{StopAliens: 2})
+		require.NoError(t, err)
+		assert.True(t, s.ShouldStop())
+
+		s, err = NewSimulation(10, 3, starMap, Options{StopAliens: 2})
+		require.NoError(t, err)
+		assert.False(t, s.ShouldStop())
+	})
+
+	t.Run("stops with few cities left", func(t *testing.T) {
+		s, err := NewSimulation(10, 1, starMap, Options{StopCities: 5})
+		require.NoError(t, err)
+		assert.True(t, s.ShouldStop())
+	})
+
+	t.Run("negative stop conditions rejected", func(t *testing.T) {
+		_, err := NewSimulation(10, 1, starMap, Options{StopAliens: -1})
+		assert.Error(t, err)
+	})
+}
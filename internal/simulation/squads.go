@@ -0,0 +1,209 @@
+package simulation
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// SquadStrategy selects how human squads move on the map.
+type SquadStrategy string
+
+const (
+	// SquadStrategyRandom moves squads along random roads like aliens.
+	SquadStrategyRandom SquadStrategy = "random"
+
+	// SquadStrategyHunt keeps squads in cities with aliens and otherwise moves them
+	// to the neighboring city with the most aliens (a random one if there are no aliens nearby).
+	SquadStrategyHunt SquadStrategy = "hunt"
+
+	// SquadStrategyGuard keeps squads in the cities they were placed in.
+	SquadStrategyGuard SquadStrategy = "guard"
+)
+
+// SquadStrategies lists all supported squad strategies.
+var SquadStrategies = []SquadStrategy{
+	SquadStrategyRandom,
+	SquadStrategyHunt,
+	SquadStrategyGuard,
+}
+
+func (o Options) validateSquads() error {
+	if o.Squads < 0 {
+		return fmt.Errorf("squads count cannot be negative")
+	}
+
+	if o.SquadDeathChance < 0 || o.SquadDeathChance > 1 {
+		return fmt.Errorf("squad death chance must be between 0 and 1")
+	}
+
+	switch o.SquadStrategy {
+	case "", SquadStrategyRandom, SquadStrategyHunt, SquadStrategyGuard:
+		return nil
+	default:
+		return fmt.Errorf("unknown squad strategy: %s", o.SquadStrategy)
+	}
+}
+
+// SquadSurvivors returns a number of surviving squads and the initial number of squads.
+func (s *Simulation) SquadSurvivors() (int, int) {
+	return len(s.squadPositions) + len(s.squadTransit), s.squadsCount
+}
+
+// placeSquads randomly assigns positions on the map for the provided squads count.
+// Squads are named ["Squad 1", "Squad 2",...].
+func (s *Simulation) placeSquads(count int) map[Squad]City {
+	cities := make([]City, 0, len(s.worldMap))
+	for city := range s.worldMap {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i] < cities[j] })
+
+	result := make(map[Squad]City, count)
+	for i := 0; i < count; i++ {
+		result[Squad(fmt.Sprintf("Squad %d", i+1))] = cities[s.rnd.Intn(len(cities))]
+	}
+
+	return result
+}
+
+// updateSquadPositions moves squads according to the squad strategy.
+// Like aliens, squads spend as many iterations travelling along a road as its length.
+func (s *Simulation) updateSquadPositions() {
+	if s.squadStrategy == SquadStrategyGuard || len(s.squadPositions)+len(s.squadTransit) == 0 {
+		return
+	}
+
+	arrived := s.advanceSquadTransit()
+
+	cityAliens := make(map[City]int)
+	for _, city := range s.alienPositions {
+		cityAliens[city] += 1
+	}
+
+	for _, squad := range s.sortedSquads() {
+		city := s.squadPositions[squad]
+		neighbors := s.worldMap[city]
+
+		if s.squadStrategy == SquadStrategyHunt && cityAliens[city] > 0 {
+			continue
+		}
+
+		possibleDirections := neighbors.directions()
+		if len(possibleDirections) == 0 {
+			continue
+		}
+
+		if s.squadStrategy == SquadStrategyHunt {
+			// keep only the roads leading to the neighboring cities with the most aliens
+			most := 0
+			var targets []Direction
+			for _, direction := range possibleDirections {
+				switch count := cityAliens[neighbors.Road(direction)]; {
+				case count > most:
+					most, targets = count, []Direction{direction}
+				case count == most:
+					targets = append(targets, direction)
+				}
+			}
+			possibleDirections = targets
+		}
+
+		direction := possibleDirections[s.rnd.Intn(len(possibleDirections))]
+		s.recordSquad(squad)
+		if length := neighbors.Length(direction); length > 1 {
+			if s.squadTransit == nil {
+				s.squadTransit = make(map[Squad]journey)
+			}
+			s.squadTransit[squad] = journey{From: city, To: neighbors.Road(direction), Length: length, Remaining: length - 1}
+			delete(s.squadPositions, squad)
+			continue
+		}
+
+		s.squadPositions[squad] = neighbors.Road(direction)
+	}
+
+	for squad, city := range arrived {
+		s.recordSquad(squad)
+		s.squadPositions[squad] = city
+	}
+}
+
+// advanceSquadTransit moves the squads in transit and returns positions of the squads which reached their destinations.
+// Squads travelling to a destroyed city die stranded on the road or turn back like aliens (see travel).
+func (s *Simulation) advanceSquadTransit() map[Squad]City {
+	arrived := make(map[Squad]City)
+
+	squads := make([]Squad, 0, len(s.squadTransit))
+	for squad := range s.squadTransit {
+		squads = append(squads, squad)
+	}
+	sort.Slice(squads, func(i, j int) bool { return squads[i] < squads[j] })
+
+	for _, squad := range squads {
+		s.recordSquad(squad)
+		j, ok := s.travel(string(squad), s.squadTransit[squad])
+		if !ok {
+			delete(s.squadTransit, squad)
+			continue
+		}
+
+		if j.Remaining > 0 {
+			s.squadTransit[squad] = j
+			continue
+		}
+
+		delete(s.squadTransit, squad)
+		arrived[squad] = j.To
+	}
+
+	return arrived
+}
+
+// evaluateSquads makes every squad kill one alien located in its city.
+// A squad dies in the fight with the squad death chance.
+func (s *Simulation) evaluateSquads() {
+	if len(s.squadPositions) == 0 {
+		return
+	}
+
+	cityAliens := make(map[City][]Alien)
+	for alien, city := range s.alienPositions {
+		cityAliens[city] = append(cityAliens[city], alien)
+	}
+	for _, aliens := range cityAliens {
+		sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
+	}
+
+	for _, squad := range s.sortedSquads() {
+		city := s.squadPositions[squad]
+
+		aliens := cityAliens[city]
+		if len(aliens) == 0 {
+			continue
+		}
+
+		idx := s.rnd.Intn(len(aliens))
+		alien := aliens[idx]
+		cityAliens[city] = append(aliens[:idx:idx], aliens[idx+1:]...)
+		s.killAliens([]Alien{alien})
+
+		if s.rnd.Float64() < s.squadDeath {
+			s.recordSquad(squad)
+			delete(s.squadPositions, squad)
+			log.Printf("%s killed %s in %s and died in the fight!", squad, alien, city)
+		} else {
+			log.Printf("%s killed %s in %s!", squad, alien, city)
+		}
+	}
+}
+
+// sortedSquads returns names of the surviving squads in the alphabetical order.
+func (s *Simulation) sortedSquads() []Squad {
+	squads := make([]Squad, 0, len(s.squadPositions))
+	for squad := range s.squadPositions {
+		squads = append(squads, squad)
+	}
+	sort.Slice(squads, func(i, j int) bool { return squads[i] < squads[j] })
+	return squads
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Squads(t *testing.T) {
+	t.Run("squad kills an alien and survives", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Centercity", "Alien 2": "Northcity"}
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+		s.evaluateSquads()
+
+		assert.Equal(t, map[Alien]City{"Alien 2": "Northcity"}, s.alienPositions)
+		assert.Len(t, s.squadPositions, 1)
+	})
+
+	t.Run("squad dies in a fight", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{SquadDeathChance: 1})
+		s.alienPositions = AlienPositions{"Alien 1": "Centercity"}
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+		s.evaluateSquads()
+
+		assert.Empty(t, s.alienPositions)
+		assert.Empty(t, s.squadPositions)
+	})
+
+	t.Run("hunting squad moves towards aliens", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{SquadStrategy: SquadStrategyHunt})
+		s.alienPositions = AlienPositions{"Alien 1": "Eastcity"}
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+		s.updateSquadPositions()
+
+		assert.Equal(t, City("Eastcity"), s.squadPositions["Squad 1"])
+	})
+
+	t.Run("squad travels along a long road", func(t *testing.T) {
+		s := newTestSimulation(t, 0, WorldMap{
+			"Foo": Neighbors{East: "Bar", Lengths: map[Direction]int{East: 3}},
+			"Bar": Neighbors{West: "Foo", Lengths: map[Direction]int{West: 3}},
+		}, Options{})
+		s.squadPositions = map[Squad]City{"Squad 1": "Foo"}
+
+		s.updateSquadPositions()
+		assert.Empty(t, s.squadPositions)
+		assert.Equal(t, journey{From: "Foo", To: "Bar", Length: 3, Remaining: 2}, s.squadTransit["Squad 1"])
+		survivors, _ := s.SquadSurvivors()
+		assert.Equal(t, 1, survivors)
+
+		s.updateSquadPositions()
+		assert.Empty(t, s.squadPositions)
+
+		s.updateSquadPositions()
+		assert.Equal(t, map[Squad]City{"Squad 1": "Bar"}, s.squadPositions)
+		assert.Empty(t, s.squadTransit)
+	})
+
+	t.Run("squad stranded on the road to a destroyed city", func(t *testing.T) {
+		s := newTestSimulation(t, 0, WorldMap{"Foo": Neighbors{}}, Options{})
+		s.squadTransit = map[Squad]journey{"Squad 1": {From: "Foo", To: "Bar", Length: 3, Remaining: 1}}
+		s.updateSquadPositions()
+
+		assert.Empty(t, s.squadPositions)
+		assert.Empty(t, s.squadTransit)
+	})
+
+	t.Run("guarding squad stays in place", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{SquadStrategy: SquadStrategyGuard})
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+		s.updateSquadPositions()
+
+		assert.Equal(t, City("Centercity"), s.squadPositions["Squad 1"])
+	})
+
+	t.Run("squad dies with its city", func(t *testing.T) {
+		s := newTestSimulation(t, 0, starMap, Options{})
+		s.squadPositions = map[Squad]City{"Squad 1": "Centercity"}
+		s.destroyCity("Centercity")
+
+		assert.Empty(t, s.squadPositions)
+	})
+
+	t.Run("squads placed on map", func(t *testing.T) {
+		s, err := NewSimulation(10, 3, simpleMap, Options{Squads: 2, SquadStrategy: SquadStrategyHunt})
+		require.NoError(t, err)
+
+		assert.Len(t, s.squadPositions, 2)
+		survivors, total := s.SquadSurvivors()
+		assert.Equal(t, 2, survivors)
+		assert.Equal(t, 2, total)
+	})
+}
@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"log"
+	"sort"
+)
+
+// journey of an alien or a squad travelling along a road longer than one iteration.
+type journey struct {
+	From   City
+	To     City
+	Length int
+
+	// Remaining is a number of iterations left to reach the destination.
+	Remaining int
+
+	// Rerouted is set if the alien turned back to the city it came from.
+	Rerouted bool
+}
+
+// depart sends an alien along a road of a provided length.
+// The alien spends length-1 iterations in transit and cannot fight in any city.
+func (s *Simulation) depart(alien Alien, from, to City, length int) {
+	s.recordAlien(alien)
+	if s.transit == nil {
+		s.transit = make(map[Alien]journey)
+	}
+	s.transit[alien] = journey{From: from, To: to, Length: length, Remaining: length - 1}
+}
+
+// advanceTransit moves the aliens in transit and returns positions of the aliens which reached their destinations.
+// If a destination is destroyed, the alien is stranded on the road and dies or, if rerouting is enabled,
+// it turns back to the city it came from.
+func (s *Simulation) advanceTransit() AlienPositions {
+	arrived := make(AlienPositions)
+
+	aliens := make([]Alien, 0, len(s.transit))
+	for alien := range s.transit {
+		aliens = append(aliens, alien)
+	}
+	sort.Slice(aliens, func(i, j int) bool { return aliens[i] < aliens[j] })
+
+	for _, alien := range aliens {
+		j, ok := s.travel(string(alien), s.transit[alien])
+		if !ok {
+			s.killAliens([]Alien{alien})
+			continue
+		}
+
+		s.recordAlien(alien)
+		if j.Remaining > 0 {
+			s.transit[alien] = j
+			continue
+		}
+
+		delete(s.transit, alien)
+		arrived[alien] = j.To
+	}
+
+	return arrived
+}
+
+// travel moves a traveller (an alien or a squad) one iteration along a road and returns the updated journey.
+// If the destination is destroyed, the traveller turns back if rerouting is enabled and the city it came from
+// still stands, otherwise it is stranded on the road and dies, which is logged, and false is returned.
+func (s *Simulation) travel(traveller string, j journey) (journey, bool) {
+	if _, ok := s.worldMap[j.To]; !ok {
+		if _, ok := s.worldMap[j.From]; !ok || !s.reroute || j.Rerouted {
+			log.Printf("%s has been stranded on the road to %s and died!", traveller, j.To)
+			return j, false
+		}
+
+		log.Printf("%s turned back to %s as %s has been destroyed!", traveller, j.From, j.To)
+
+		// the way back takes as long as the traveller has already travelled
+		j = journey{From: j.To, To: j.From, Length: j.Length, Remaining: j.Length - j.Remaining, Rerouted: true}
+	}
+
+	j.Remaining -= 1
+	return j, true
+}
@@ -0,0 +1,79 @@
+package simulation
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Transit(t *testing.T) {
+	longRoadMap := WorldMap{
+		"Talihina": Neighbors{South: "Pinson", Lengths: map[Direction]int{South: 3}},
+		"Pinson":   Neighbors{North: "Talihina", Lengths: map[Direction]int{North: 3}},
+	}
+
+	t.Run("alien spends several iterations on a long road", func(t *testing.T) {
+		s := newTestSimulation(t, 100, longRoadMap, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina"}
+
+		s.Step()
+		assert.Empty(t, s.alienPositions)
+		assert.False(t, s.ShouldStop())
+
+		s.Step()
+		assert.Empty(t, s.alienPositions)
+
+		s.Step()
+		assert.Equal(t, map[Alien]City{"Alien 1": "Pinson"}, s.alienPositions)
+		assert.Empty(t, s.transit)
+	})
+
+	t.Run("aliens in transit do not fight", func(t *testing.T) {
+		s := newTestSimulation(t, 100, longRoadMap, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina", "Alien 2": "Pinson"}
+
+		s.Step()
+		s.Step()
+		s.Step()
+
+		assert.Equal(t, map[Alien]City{"Alien 1": "Pinson", "Alien 2": "Talihina"}, s.alienPositions)
+	})
+
+	t.Run("alien stranded when destination is destroyed", func(t *testing.T) {
+		s := newTestSimulation(t, 100, longRoadMap, Options{})
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina"}
+
+		var out bytes.Buffer
+		log.SetOutput(&out)
+		defer log.SetOutput(os.Stderr)
+
+		s.Step()
+		s.destroyCity("Pinson")
+		s.Step()
+
+		assert.Empty(t, s.alienPositions)
+		assert.Empty(t, s.transit)
+		assert.True(t, s.ShouldStop())
+		assert.Contains(t, out.String(), "Alien 1 has been stranded on the road to Pinson and died!")
+	})
+
+	t.Run("alien turns back when destination is destroyed", func(t *testing.T) {
+		s := newTestSimulation(t, 100, longRoadMap, Options{Reroute: true})
+		s.alienPositions = AlienPositions{"Alien 1": "Talihina"}
+
+		s.Step()
+		s.Step()
+		s.destroyCity("Pinson")
+
+		// the way back takes as long as the alien has already travelled
+		s.Step()
+		assert.Empty(t, s.alienPositions)
+		assert.Equal(t, City("Talihina"), s.transit["Alien 1"].To)
+
+		s.Step()
+		assert.Equal(t, map[Alien]City{"Alien 1": "Talihina"}, s.alienPositions)
+	})
+}
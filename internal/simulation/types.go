@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -13,19 +14,38 @@ func (wm WorldMap) String() string {
 
 	for city, neighbors := range wm {
 		parts := make([]string, 0, 5)
-		parts = append(parts, string(city))
-
-		if neighbors.North != "" {
-			parts = append(parts, fmt.Sprintf("north=%s", neighbors.North))
+		if neighbors.Coordinates != nil {
+			parts = append(parts, fmt.Sprintf("%s@%d,%d", city, neighbors.Coordinates.X, neighbors.Coordinates.Y))
+		} else {
+			parts = append(parts, string(city))
 		}
-		if neighbors.South != "" {
-			parts = append(parts, fmt.Sprintf("south=%s", neighbors.South))
+
+		if neighbors.HP != 0 {
+			parts = append(parts, fmt.Sprintf("hp=%d", neighbors.HP))
 		}
-		if neighbors.East != "" {
-			parts = append(parts, fmt.Sprintf("east=%s", neighbors.East))
+		if neighbors.Defense != 0 {
+			parts = append(parts, fmt.Sprintf("defense=%d", neighbors.Defense))
 		}
-		if neighbors.West != "" {
-			parts = append(parts, fmt.Sprintf("west=%s", neighbors.West))
+
+		for _, direction := range neighbors.directions() {
+			city := neighbors.Road(direction)
+
+			road := string(city)
+			if neighbors.OneWay[direction] {
+				road = ">" + road
+			}
+			if length, ok := neighbors.Lengths[direction]; ok {
+				road = fmt.Sprintf("%s:%d", road, length)
+			}
+			if cost, ok := neighbors.Costs[direction]; ok {
+				road = fmt.Sprintf("%s$%d", road, cost)
+			}
+
+			key := string(direction)
+			if !direction.IsCompass() {
+				key = ExitPrefix + key
+			}
+			parts = append(parts, fmt.Sprintf("%s=%s", key, road))
 		}
 
 		sb.WriteString(strings.Join(parts, " ") + "\n")
@@ -35,12 +55,289 @@ func (wm WorldMap) String() string {
 }
 
 // Neighbors respresent connections to other cities.
-// nil pointer represents no connection for a direction.
+// A compass direction field holds the name of the city the road leads to, an empty string represents
+// no road in that direction. Roads in named directions are kept in Exits, while Lengths, Costs and OneWay
+// hold optional attributes of the roads in both kinds of directions.
 type Neighbors struct {
-	North City
-	South City
-	East  City
-	West  City
+	North     City `json:"north,omitempty"`
+	South     City `json:"south,omitempty"`
+	East      City `json:"east,omitempty"`
+	West      City `json:"west,omitempty"`
+	NorthEast City `json:"northeast,omitempty"`
+	NorthWest City `json:"northwest,omitempty"`
+	SouthEast City `json:"southeast,omitempty"`
+	SouthWest City `json:"southwest,omitempty"`
+
+	// Exits are roads leading in named directions other than the compass directions, e.g. exit:portal=Foo.
+	Exits map[Direction]City `json:"exits,omitempty"`
+
+	// Coordinates of the city on a grid, nil if unknown.
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+
+	// HP is a number of hit points of the city, 1 if not set. Every fight in the city
+	// deals one damage less than the number of fighting aliens and the city falls at 0 hit points.
+	HP int `json:"hp,omitempty"`
+
+	// Defense of the city. A lone alien in the city is killed by defenders
+	// with a probability of defense/(defense+1) in every iteration.
+	Defense int `json:"defense,omitempty"`
+
+	// Lengths of the roads leading out of the city, 1 if not set.
+	Lengths map[Direction]int `json:"lengths,omitempty"`
+
+	// Costs of the roads leading out of the city paid in fuel by every travelling alien, 1 if not set.
+	Costs map[Direction]int `json:"costs,omitempty"`
+
+	// OneWay marks roads which intentionally have no road leading back.
+	OneWay map[Direction]bool `json:"oneWay,omitempty"`
+}
+
+// Direction of a road leading out of a city.
+type Direction string
+
+const (
+	North     Direction = "north"
+	South     Direction = "south"
+	East      Direction = "east"
+	West      Direction = "west"
+	NorthEast Direction = "northeast"
+	NorthWest Direction = "northwest"
+	SouthEast Direction = "southeast"
+	SouthWest Direction = "southwest"
+)
+
+// ExitPrefix marks a named exit in the map file format, e.g. exit:portal=Foo.
+const ExitPrefix = "exit:"
+
+// Directions lists all compass directions of the roads in the order used by the map file format.
+var Directions = []Direction{North, South, East, West, NorthEast, NorthWest, SouthEast, SouthWest}
+
+// IsCompass reports whether the direction is one of the compass directions rather than a named exit.
+func (d Direction) IsCompass() bool {
+	for _, direction := range Directions {
+		if d == direction {
+			return true
+		}
+	}
+	return false
+}
+
+// Opposite returns a direction of the road leading back.
+// Named exits have no opposite direction and an empty direction is returned.
+func (d Direction) Opposite() Direction {
+	switch d {
+	case North:
+		return South
+	case South:
+		return North
+	case East:
+		return West
+	case West:
+		return East
+	case NorthEast:
+		return SouthWest
+	case NorthWest:
+		return SouthEast
+	case SouthEast:
+		return NorthWest
+	case SouthWest:
+		return NorthEast
+	}
+	return ""
+}
+
+// Road returns a city connected by a road leading in a provided direction.
+func (n Neighbors) Road(direction Direction) City {
+	switch direction {
+	case North:
+		return n.North
+	case South:
+		return n.South
+	case East:
+		return n.East
+	case West:
+		return n.West
+	case NorthEast:
+		return n.NorthEast
+	case NorthWest:
+		return n.NorthWest
+	case SouthEast:
+		return n.SouthEast
+	case SouthWest:
+		return n.SouthWest
+	}
+	return n.Exits[direction]
+}
+
+// SetRoad connects a city by a road leading in a provided direction, an empty city removes the road.
+func (n *Neighbors) SetRoad(direction Direction, city City) {
+	switch direction {
+	case North:
+		n.North = city
+	case South:
+		n.South = city
+	case East:
+		n.East = city
+	case West:
+		n.West = city
+	case NorthEast:
+		n.NorthEast = city
+	case NorthWest:
+		n.NorthWest = city
+	case SouthEast:
+		n.SouthEast = city
+	case SouthWest:
+		n.SouthWest = city
+	default:
+		if city == "" {
+			delete(n.Exits, direction)
+			if len(n.Exits) == 0 {
+				n.Exits = nil
+			}
+			return
+		}
+
+		if n.Exits == nil {
+			n.Exits = make(map[Direction]City)
+		}
+		n.Exits[direction] = city
+	}
+}
+
+// Length returns a length of the road leading in a provided direction.
+func (n Neighbors) Length(direction Direction) int {
+	if length, ok := n.Lengths[direction]; ok {
+		return length
+	}
+	return 1
+}
+
+// Cost returns a fuel cost of the road leading in a provided direction.
+func (n Neighbors) Cost(direction Direction) int {
+	if cost, ok := n.Costs[direction]; ok {
+		return cost
+	}
+	return 1
+}
+
+// directions returns directions of the roads leading out of the city.
+// Compass directions are followed by named exits in the alphabetical order.
+func (n Neighbors) directions() []Direction {
+	result := make([]Direction, 0, len(Directions)+len(n.Exits))
+	for _, direction := range Directions {
+		if n.Road(direction) != "" {
+			result = append(result, direction)
+		}
+	}
+
+	exits := make([]Direction, 0, len(n.Exits))
+	for direction, city := range n.Exits {
+		if city != "" {
+			exits = append(exits, direction)
+		}
+	}
+	sort.Slice(exits, func(i, j int) bool { return exits[i] < exits[j] })
+
+	return append(result, exits...)
+}
+
+// copy returns a copy of the neighbors which does not share the properties of the roads.
+func (n Neighbors) copy() Neighbors {
+	if n.Exits != nil {
+		exits := make(map[Direction]City, len(n.Exits))
+		for direction, city := range n.Exits {
+			exits[direction] = city
+		}
+		n.Exits = exits
+	}
+	if n.Lengths != nil {
+		lengths := make(map[Direction]int, len(n.Lengths))
+		for direction, length := range n.Lengths {
+			lengths[direction] = length
+		}
+		n.Lengths = lengths
+	}
+	if n.Costs != nil {
+		costs := make(map[Direction]int, len(n.Costs))
+		for direction, cost := range n.Costs {
+			costs[direction] = cost
+		}
+		n.Costs = costs
+	}
+	if n.OneWay != nil {
+		oneWay := make(map[Direction]bool, len(n.OneWay))
+		for direction, value := range n.OneWay {
+			oneWay[direction] = value
+		}
+		n.OneWay = oneWay
+	}
+	return n
+}
+
+// Validate checks that every road leads to a city of the map and has a matching road
+// leading back in the opposite direction, unless the road is marked one-way.
+// A named exit can be matched by a road leading back in any direction.
+// Coordinates of the cities cannot be negative or shared by two cities.
+func (wm WorldMap) Validate() error {
+	positions := make(map[Coordinates]City, len(wm))
+
+	for city, neighbors := range wm {
+		if c := neighbors.Coordinates; c != nil {
+			if c.X < 0 || c.Y < 0 {
+				return fmt.Errorf("city %s has negative coordinates %d,%d", city, c.X, c.Y)
+			}
+			if other, ok := positions[*c]; ok {
+				return fmt.Errorf("cities %s and %s share coordinates %d,%d", other, city, c.X, c.Y)
+			}
+			positions[*c] = city
+		}
+
+		for _, direction := range neighbors.directions() {
+			target := neighbors.Road(direction)
+
+			targetNeighbors, ok := wm[target]
+			if !ok {
+				return fmt.Errorf("road %s=%s of %s leads to an unknown city", direction, target, city)
+			}
+
+			if !neighbors.OneWay[direction] && !targetNeighbors.leadsBack(direction, city) {
+				return fmt.Errorf(
+					"road %s=%s of %s has no road leading back (mark it as one-way: %s=>%s)",
+					direction, target, city, direction, target,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// leadsBack reports whether there is a road matching a road leading in a provided direction to the city.
+func (n Neighbors) leadsBack(direction Direction, city City) bool {
+	if direction.IsCompass() && n.Road(direction.Opposite()) == city {
+		return true
+	}
+
+	for _, back := range n.directions() {
+		if n.Road(back) == city && (!back.IsCompass() || !direction.IsCompass()) {
+			return true
+		}
+	}
+	return false
+}
+
+// hp returns remaining hit points of the city.
+func (n Neighbors) hp() int {
+	if n.HP == 0 {
+		return 1
+	}
+	return n.HP
+}
+
+// Coordinates represent a cell of a grid, X being the column and Y the row.
+type Coordinates struct {
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 type City string
@@ -48,3 +345,18 @@ type City string
 type AlienPositions map[Alien]City
 
 type Alien string
+
+// Squad is a human resistance unit killing aliens.
+type Squad string
+
+// Faction is a team of aliens which do not fight each other.
+type Faction struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// FactionSurvivors is a number of aliens of a faction which survived the invasion.
+type FactionSurvivors struct {
+	Faction
+	Survivors int
+}
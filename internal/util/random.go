@@ -0,0 +1,44 @@
+package util
+
+// Source is a source of random numbers with a state which can be saved and restored,
+// unlike the sources of the math/rand package. SplitMix64 algorithm is used.
+type Source struct {
+	state uint64
+}
+
+// NewSource returns a Source initialized with a provided seed.
+func NewSource(seed int64) *Source {
+	s := &Source{}
+	s.Seed(seed)
+	return s
+}
+
+// Seed initializes the source with a provided seed.
+func (s *Source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (s *Source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// State returns the current state of the source.
+func (s *Source) State() uint64 {
+	return s.state
+}
+
+// SetState restores a state returned by State.
+func (s *Source) SetState(state uint64) {
+	s.state = state
+}
@@ -0,0 +1,39 @@
+package util
+
+// UnionFind is a disjoint-set data structure storing a partition of elements 0..n-1.
+type UnionFind struct {
+	parent []int
+}
+
+// NewUnionFind returns a UnionFind with every element in a separate set.
+func NewUnionFind(n int) *UnionFind {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &UnionFind{parent: parent}
+}
+
+// Len returns the number of elements.
+func (uf *UnionFind) Len() int {
+	return len(uf.parent)
+}
+
+// Find returns the representative element of the set containing i.
+func (uf *UnionFind) Find(i int) int {
+	for uf.parent[i] != i {
+		uf.parent[i] = uf.parent[uf.parent[i]]
+		i = uf.parent[i]
+	}
+	return i
+}
+
+// Union merges sets containing i and j and returns false if they were already merged.
+func (uf *UnionFind) Union(i, j int) bool {
+	i, j = uf.Find(i), uf.Find(j)
+	if i == j {
+		return false
+	}
+	uf.parent[i] = j
+	return true
+}
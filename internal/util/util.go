@@ -16,3 +16,13 @@ func Write(filepath string, text string) error {
 
 	return nil
 }
+
+// WriteAtomic writes data to a temporary file and renames it to the provided filepath,
+// so that the file is never left partially written.
+func WriteAtomic(filepath string, data []byte) error {
+	tmp := filepath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath)
+}
@@ -3,14 +3,21 @@ package world
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/maruqu/alien-invasion/internal/simulation"
+	"github.com/maruqu/alien-invasion/internal/util"
 )
 
 // Load reads and parses a world map from a provided file.
-// Map structure is not validated.
+// A city name can be optionally followed by its grid coordinates, e.g. Foo@2,3,
+// and its attributes, e.g. Foo hp=3 defense=1.
+// A road can be optionally followed by its length, e.g. north=Bar:3, and its fuel cost, e.g. north=Bar:3$2,
+// and marked as one-way, e.g. north=>Bar.
+// Besides the eight compass directions, roads can lead through named exits, e.g. exit:portal=Bar.
 func Load(filepath string) (simulation.WorldMap, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -18,9 +25,14 @@ func Load(filepath string) (simulation.WorldMap, error) {
 	}
 	defer file.Close()
 
+	return Parse(file)
+}
+
+// Parse parses a world map in the map file format (see Load).
+func Parse(r io.Reader) (simulation.WorldMap, error) {
 	var lines []string
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
@@ -35,32 +47,146 @@ func Load(filepath string) (simulation.WorldMap, error) {
 			return nil, fmt.Errorf("error parsing map file: line without city")
 		}
 
+		name, coordinates, err := parseCity(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing map file: %w", err)
+		}
+		neighbors.Coordinates = coordinates
+
 		for _, part := range parts[1:] {
 			directionCity := strings.Split(part, "=")
 			if len(directionCity) != 2 {
 				return nil, fmt.Errorf("error parsing map file: invalid road: %s", part)
 			}
 
-			city := simulation.City(directionCity[1])
-
 			switch directionCity[0] {
-			case "north":
-				neighbors.North = city
-			case "south":
-				neighbors.South = city
-			case "east":
-				neighbors.East = city
-			case "west":
-				neighbors.West = city
+			case "hp", "defense":
+				value, err := strconv.Atoi(directionCity[1])
+				if err != nil || value < 0 {
+					return nil, fmt.Errorf("error parsing map file: invalid city attribute: %s", part)
+				}
+
+				if directionCity[0] == "hp" {
+					neighbors.HP = value
+				} else {
+					neighbors.Defense = value
+				}
+			default:
+				// compass directions and named exits
+				direction, err := parseDirection(directionCity[0])
+				if err != nil {
+					return nil, fmt.Errorf("error parsing map file: %w", err)
+				}
+
+				city, length, cost, err := parseRoad(directionCity[1])
+				if err != nil {
+					return nil, fmt.Errorf("error parsing map file: %w", err)
+				}
+
+				if strings.HasPrefix(string(city), ">") {
+					city = city[1:]
+					if neighbors.OneWay == nil {
+						neighbors.OneWay = make(map[simulation.Direction]bool)
+					}
+					neighbors.OneWay[direction] = true
+				}
+
+				neighbors.SetRoad(direction, city)
+				if length != 0 {
+					if neighbors.Lengths == nil {
+						neighbors.Lengths = make(map[simulation.Direction]int)
+					}
+					neighbors.Lengths[direction] = length
+				}
+				if cost != 0 {
+					if neighbors.Costs == nil {
+						neighbors.Costs = make(map[simulation.Direction]int)
+					}
+					neighbors.Costs[direction] = cost
+				}
 			}
 		}
 
-		worldMap[simulation.City(parts[0])] = neighbors
+		worldMap[name] = neighbors
 	}
 
 	return worldMap, nil
 }
 
+// parseDirection parses a compass direction or a named exit prefixed with simulation.ExitPrefix.
+// Any other key is rejected, so that a misspelled direction, e.g. nrth=Bar, does not become a named exit.
+func parseDirection(text string) (simulation.Direction, error) {
+	if strings.HasPrefix(text, simulation.ExitPrefix) {
+		exit := simulation.Direction(strings.TrimPrefix(text, simulation.ExitPrefix))
+		if exit == "" || exit.IsCompass() {
+			return "", fmt.Errorf("invalid exit: %s", text)
+		}
+		return exit, nil
+	}
+
+	direction := simulation.Direction(text)
+	if !direction.IsCompass() {
+		return "", fmt.Errorf("unknown road direction: %s", text)
+	}
+	return direction, nil
+}
+
+// parseCity parses a city name optionally followed by coordinates in format name@x,y.
+// Coordinates cannot be negative.
+// A city name cannot start with '>' which marks a one-way road leading to the city.
+func parseCity(text string) (simulation.City, *simulation.Coordinates, error) {
+	if strings.HasPrefix(text, ">") {
+		return "", nil, fmt.Errorf("invalid city name: %s", text)
+	}
+
+	idx := strings.LastIndex(text, "@")
+	if idx == -1 {
+		return simulation.City(text), nil, nil
+	}
+
+	xy := strings.Split(text[idx+1:], ",")
+	if len(xy) != 2 {
+		return "", nil, fmt.Errorf("invalid coordinates: %s", text)
+	}
+
+	x, err := strconv.Atoi(xy[0])
+	if err != nil || x < 0 {
+		return "", nil, fmt.Errorf("invalid coordinates: %s", text)
+	}
+
+	y, err := strconv.Atoi(xy[1])
+	if err != nil || y < 0 {
+		return "", nil, fmt.Errorf("invalid coordinates: %s", text)
+	}
+
+	return simulation.City(text[:idx]), &simulation.Coordinates{X: x, Y: y}, nil
+}
+
+// parseRoad parses a city optionally followed by a length of the road leading to it and a fuel cost
+// of the road in format name:length$cost. Zero length and cost are returned if they are not provided.
+func parseRoad(text string) (simulation.City, int, int, error) {
+	cost := 0
+	if idx := strings.LastIndex(text, "$"); idx != -1 {
+		value, err := strconv.Atoi(text[idx+1:])
+		if err != nil || value < 1 {
+			return "", 0, 0, fmt.Errorf("invalid road cost: %s", text)
+		}
+		text, cost = text[:idx], value
+	}
+
+	idx := strings.LastIndex(text, ":")
+	if idx == -1 {
+		return simulation.City(text), 0, cost, nil
+	}
+
+	length, err := strconv.Atoi(text[idx+1:])
+	if err != nil || length < 1 {
+		return "", 0, 0, fmt.Errorf("invalid road length: %s", text)
+	}
+
+	return simulation.City(text[:idx]), length, cost, nil
+}
+
 // Save writes a world map to a provided filepath.
 func Save(filepath string, worldMap simulation.WorldMap) error {
 	file, err := os.Create(filepath)
@@ -73,3 +199,28 @@ func Save(filepath string, worldMap simulation.WorldMap) error {
 
 	return nil
 }
+
+// LoadPlacement reads a fixed placement of aliens from a provided file.
+// Every line contains an alien name followed by a city name, e.g. Zorg Foo.
+// Alien names may contain whitespaces, the last word of the line is the city.
+func LoadPlacement(filepath string) ([]simulation.PlacedAlien, error) {
+	lines, err := util.ReadLines(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	placement := make([]simulation.PlacedAlien, 0, len(lines))
+	for _, line := range lines {
+		idx := strings.LastIndexAny(line, " \t")
+		if idx == -1 {
+			return nil, fmt.Errorf("error parsing placement file: line without city: %s", line)
+		}
+
+		placement = append(placement, simulation.PlacedAlien{
+			Alien: simulation.Alien(strings.TrimSpace(line[:idx])),
+			City:  simulation.City(line[idx+1:]),
+		})
+	}
+
+	return placement, nil
+}
@@ -1,7 +1,9 @@
 package world
 
 import (
+	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/maruqu/alien-invasion/internal/simulation"
@@ -22,17 +24,126 @@ var (
 			West: "Pinson",
 		},
 	}
+
+	testMapWithCoordinates = simulation.WorldMap{
+		"Talihina": simulation.Neighbors{
+			South:       "Pinson",
+			Coordinates: &simulation.Coordinates{X: 0, Y: 0},
+		},
+		"Pinson": simulation.Neighbors{
+			North:       "Talihina",
+			East:        "Fabens",
+			Coordinates: &simulation.Coordinates{X: 0, Y: 2},
+		},
+		"Fabens": simulation.Neighbors{
+			West:        "Pinson",
+			Coordinates: &simulation.Coordinates{X: 3, Y: 2},
+		},
+	}
+
+	testMapWithAttributes = simulation.WorldMap{
+		"Talihina": simulation.Neighbors{
+			South:   "Pinson",
+			East:    "Fabens",
+			HP:      3,
+			Defense: 1,
+			OneWay:  map[simulation.Direction]bool{simulation.East: true},
+			Lengths: map[simulation.Direction]int{simulation.East: 2},
+			Costs:   map[simulation.Direction]int{simulation.East: 4, simulation.South: 2},
+		},
+		"Pinson": simulation.Neighbors{
+			North:   "Talihina",
+			Lengths: map[simulation.Direction]int{simulation.North: 3},
+		},
+	}
+
+	testMapWithDiagonals = simulation.WorldMap{
+		"Talihina": simulation.Neighbors{
+			SouthEast: "Pinson",
+			Exits:     map[simulation.Direction]simulation.City{"portal": "Fabens"},
+		},
+		"Pinson": simulation.Neighbors{
+			NorthWest: "Talihina",
+			SouthWest: "Fabens",
+		},
+		"Fabens": simulation.Neighbors{
+			NorthEast: "Pinson",
+			Exits:     map[simulation.Direction]simulation.City{"portal": "Talihina"},
+		},
+	}
 )
 
 func Test_Save_Load(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		worldMap simulation.WorldMap
+	}{
+		{"roads", testMap},
+		{"coordinates", testMapWithCoordinates},
+		{"attributes", testMapWithAttributes},
+		{"diagonals", testMapWithDiagonals},
+	} {
+		t.Run(tc.name+" preserved", func(t *testing.T) {
+			filepath := path.Join(t.TempDir(), "test.map")
+
+			err := Save(filepath, tc.worldMap)
+			require.NoError(t, err)
+
+			loadedMap, err := Load(filepath)
+			require.NoError(t, err)
+
+			assert.EqualValues(t, tc.worldMap, loadedMap)
+		})
+	}
+}
+
+func Test_Parse_InvalidCoordinates(t *testing.T) {
+	t.Run("negative coordinates rejected", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("Talihina@-1,0\n"))
+		assert.Error(t, err)
+
+		_, err = Parse(strings.NewReader("Talihina@0,-2\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("city name marking a one-way road rejected", func(t *testing.T) {
+		_, err := Parse(strings.NewReader(">Talihina south=Pinson\nPinson north=>Talihina\n"))
+		assert.Error(t, err)
+	})
+}
+
+func Test_Parse_InvalidDirections(t *testing.T) {
+	t.Run("misspelled direction rejected", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("Talihina nrth=Pinson\nPinson south=Talihina\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid exits rejected", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("Talihina exit:=Pinson\n"))
+		assert.Error(t, err)
+
+		_, err = Parse(strings.NewReader("Talihina exit:north=Pinson\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("named exit parsed", func(t *testing.T) {
+		worldMap, err := Parse(strings.NewReader("Talihina exit:portal=Pinson\nPinson exit:portal=Talihina\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, simulation.City("Pinson"), worldMap["Talihina"].Road("portal"))
+		assert.Equal(t, simulation.City("Talihina"), worldMap["Pinson"].Road("portal"))
+	})
+}
+
+func Test_LoadPlacement(t *testing.T) {
 	tempDir := t.TempDir()
-	filepath := path.Join(tempDir, "test.map")
+	filepath := path.Join(tempDir, "placement.txt")
 
-	err := Save(filepath, testMap)
+	err := os.WriteFile(filepath, []byte("Zorg Pinson\nBig Blop Fabens\n\n"), 0644)
 	require.NoError(t, err)
 
-	loadedMap, err := Load(filepath)
+	placement, err := LoadPlacement(filepath)
 	require.NoError(t, err)
 
-	assert.EqualValues(t, testMap, loadedMap)
+	assert.Equal(t, []simulation.PlacedAlien{{Alien: "Zorg", City: "Pinson"}, {Alien: "Big Blop", City: "Fabens"}}, placement)
 }